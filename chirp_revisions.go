@@ -0,0 +1,144 @@
+package main
+
+import (
+    "encoding/json"
+    "net/http"
+    "time"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/database"
+)
+
+type ChirpRevision struct {
+    ID         uuid.UUID `json:"id"`
+    ChirpID    uuid.UUID `json:"chirp_id"`
+    Body       string    `json:"body"`
+    CreatedAt  time.Time `json:"created_at"`
+    ReplacedAt time.Time `json:"replaced_at"`
+}
+
+func (cfg *apiConfig) updateChirpHandler(w http.ResponseWriter, r *http.Request) {
+    type params struct {
+        Body string `json:"body"`
+    }
+
+    chirp, ok := cfg.ownedChirp(w, r)
+    if !ok {
+        return
+    }
+
+    // Giving a plain rechirp a body would turn it into a quote chirp and let
+    // the user rechirp the same original again, and emptying a quote chirp
+    // would do the reverse.
+    if isPlainRechirp(chirp) {
+        http.Error(w, "Plain rechirps cannot be edited", http.StatusConflict)
+        return
+    }
+
+    var p params
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, "Invalid request", http.StatusBadRequest)
+        return
+    }
+    if chirp.IsRepost && p.Body == "" {
+        http.Error(w, "A quote chirp needs a body", http.StatusBadRequest)
+        return
+    }
+
+    cleaned, err := cfg.validateChirpBody(p.Body)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    tx, err := cfg.db.BeginTx(r.Context(), nil)
+    if err != nil {
+        http.Error(w, "Failed to update chirp", http.StatusInternalServerError)
+        return
+    }
+    defer tx.Rollback()
+
+    qtx := cfg.DB.WithTx(tx)
+
+    // Lock the row so concurrent edits can't both snapshot the same version.
+    current, err := qtx.GetChirpForUpdate(r.Context(), chirp.ID)
+    if err != nil {
+        http.Error(w, "No such chirp", http.StatusNotFound)
+        return
+    }
+
+    _, err = qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+        ChirpID:   current.ID,
+        Body:      current.Body,
+        CreatedAt: current.UpdatedAt,
+    })
+    if err != nil {
+        http.Error(w, "Failed to update chirp", http.StatusInternalServerError)
+        return
+    }
+
+    updated, err := qtx.UpdateChirp(r.Context(), database.UpdateChirpParams{
+        ID:   current.ID,
+        Body: cleaned,
+    })
+    if err != nil {
+        http.Error(w, "Failed to update chirp", http.StatusInternalServerError)
+        return
+    }
+
+    if err := indexChirpEntities(r.Context(), qtx, updated); err != nil {
+        http.Error(w, "Failed to update chirp", http.StatusInternalServerError)
+        return
+    }
+
+    if err := tx.Commit(); err != nil {
+        http.Error(w, "Failed to update chirp", http.StatusInternalServerError)
+        return
+    }
+
+    result := []Chirp{toChirp(updated)}
+    if err := cfg.hydrateChirps(r.Context(), updated.UserID, result); err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(result[0])
+}
+
+func (cfg *apiConfig) chirpRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+    chirpID, err := uuid.Parse(r.PathValue("chirpId"))
+    if err != nil {
+        http.Error(w, "invalid chirpId", http.StatusBadRequest)
+        return
+    }
+
+    chirp, err := cfg.DB.GetChirp(r.Context(), chirpID)
+    if err != nil || !visibleTo(chirp, cfg.optionalUser(r)) {
+        http.Error(w, "No such chirp", http.StatusNotFound)
+        return
+    }
+
+    revisions, err := cfg.DB.GetChirpRevisions(r.Context(), chirpID)
+    if err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    resp := make([]ChirpRevision, 0, len(revisions))
+    for _, rev := range revisions {
+        resp = append(resp, ChirpRevision{
+            ID:         rev.ID,
+            ChirpID:    rev.ChirpID,
+            Body:       rev.Body,
+            CreatedAt:  rev.CreatedAt,
+            ReplacedAt: rev.ReplacedAt,
+        })
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(resp)
+}
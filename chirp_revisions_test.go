@@ -0,0 +1,50 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "testing"
+
+    "github.com/google/uuid"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "github.com/danon29/chippy/internal/auth"
+    "github.com/danon29/chippy/internal/database"
+)
+
+func TestUpdateChirp_Rechirps(t *testing.T) {
+    cfg := newTestConfig(t)
+    author := createTestUser(t, cfg)
+    original, err := cfg.DB.CreateChirp(context.Background(), database.CreateChirpParams{
+        Body:   "hello",
+        UserID: author.ID,
+    })
+    require.NoError(t, err)
+
+    user := createTestUser(t, cfg)
+    token, _ := login(t, cfg, user)
+    rechirp := func(body any) Chirp {
+        rec := serve(cfg.middlewareRequireScope(auth.ScopeChirpsWrite, cfg.rechirpHandler).ServeHTTP,
+            http.MethodPost, "/api/chirps/"+original.ID.String()+"/rechirps", token, body,
+            "chirpId", original.ID.String())
+        require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+        var chirp Chirp
+        require.NoError(t, json.NewDecoder(rec.Body).Decode(&chirp))
+        return chirp
+    }
+    edit := func(chirpID uuid.UUID, body string) int {
+        rec := serve(cfg.middlewareRequireScope(auth.ScopeChirpsWrite, cfg.updateChirpHandler).ServeHTTP,
+            http.MethodPut, "/api/chirps/"+chirpID.String(), token, map[string]string{"body": body},
+            "chirpId", chirpID.String())
+        return rec.Code
+    }
+
+    plain := rechirp(nil)
+    assert.Equal(t, http.StatusConflict, edit(plain.ID, "now a quote"))
+
+    quote := rechirp(map[string]string{"body": "look at this"})
+    assert.Equal(t, http.StatusBadRequest, edit(quote.ID, ""))
+    assert.Equal(t, http.StatusOK, edit(quote.ID, "look at this!"))
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/auth"
+    "github.com/danon29/chippy/internal/database"
+    "github.com/danon29/chippy/internal/denylist"
+)
+
+const denylistReloadInterval = 30 * time.Second
+
+var errTokenRevoked = errors.New("token has been revoked")
+
+// dbDenylistStore keeps revoked access tokens in the denied_tokens,
+// user_token_revocations and denied_sessions tables.
+type dbDenylistStore struct {
+    q *database.Queries
+}
+
+func (s dbDenylistStore) ListTokens(ctx context.Context) (map[string]time.Time, error) {
+    rows, err := s.q.ListDeniedTokens(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    tokens := make(map[string]time.Time, len(rows))
+    for _, row := range rows {
+        tokens[row.Jti] = row.ExpiresAt
+    }
+    return tokens, nil
+}
+
+func (s dbDenylistStore) ListUsers(ctx context.Context) ([]denylist.UserRevocation, error) {
+    rows, err := s.q.ListUserTokenRevocations(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    revs := make([]denylist.UserRevocation, 0, len(rows))
+    for _, row := range rows {
+        revs = append(revs, denylist.UserRevocation(row))
+    }
+    return revs, nil
+}
+
+func (s dbDenylistStore) ListSessions(ctx context.Context) (map[uuid.UUID]time.Time, error) {
+    rows, err := s.q.ListDeniedSessions(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    sessions := make(map[uuid.UUID]time.Time, len(rows))
+    for _, row := range rows {
+        sessions[row.SessionID] = row.ExpiresAt
+    }
+    return sessions, nil
+}
+
+func (s dbDenylistStore) AddToken(ctx context.Context, jti string, expiresAt time.Time) error {
+    return s.q.DenyToken(ctx, database.DenyTokenParams{Jti: jti, ExpiresAt: expiresAt})
+}
+
+func (s dbDenylistStore) AddUser(ctx context.Context, rev denylist.UserRevocation) error {
+    return s.q.RevokeUserTokens(ctx, database.RevokeUserTokensParams(rev))
+}
+
+func (s dbDenylistStore) AddSession(ctx context.Context, sessionID uuid.UUID, expiresAt time.Time) error {
+    return s.q.DenySession(ctx, database.DenySessionParams{SessionID: sessionID, ExpiresAt: expiresAt})
+}
+
+func (s dbDenylistStore) DeleteExpired(ctx context.Context) error {
+    if err := s.q.DeleteExpiredDeniedTokens(ctx); err != nil {
+        return err
+    }
+    if err := s.q.DeleteExpiredUserTokenRevocations(ctx); err != nil {
+        return err
+    }
+    return s.q.DeleteExpiredDeniedSessions(ctx)
+}
+
+// reloadDenylist periodically picks up tokens revoked by other instances.
+func (cfg *apiConfig) reloadDenylist(ctx context.Context) {
+    ticker := time.NewTicker(denylistReloadInterval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if err := cfg.denylist.Reload(ctx); err != nil {
+                log.Printf("Error reloading token denylist: %v", err)
+            }
+        }
+    }
+}
+
+// validateAccessToken is auth.ValidateJWTWithClaims that also refuses tokens
+// on the denylist.
+func (cfg *apiConfig) validateAccessToken(token string) (*auth.Claims, error) {
+    claims, err := auth.ValidateJWTWithClaims(token, cfg.jwtKeys)
+    if err != nil {
+        return nil, err
+    }
+
+    userID, _ := uuid.Parse(claims.Subject)
+    sessionID, _ := uuid.Parse(claims.SessionID)
+    if cfg.denylist.IsRevoked(claims.ID, userID, sessionID, claims.IssuedAt.Time) {
+        return nil, errTokenRevoked
+    }
+
+    return claims, nil
+}
+
+// revokeAllUserTokens logs a user out everywhere: their refresh tokens are
+// revoked and every access token issued so far is denied for as long as the
+// longest-lived of them could still be valid. Access tokens are denied by
+// session, so logging in again straight away gets a working token.
+func (cfg *apiConfig) revokeAllUserTokens(ctx context.Context, userID uuid.UUID) error {
+    if err := cfg.DB.RevokeUserRefreshTokens(ctx, userID); err != nil {
+        return err
+    }
+
+    sessionIDs, err := cfg.DB.ListUserSessionIDs(ctx, userID)
+    if err != nil {
+        return err
+    }
+    for _, sessionID := range sessionIDs {
+        if err := cfg.denylist.RevokeSession(ctx, sessionID, cfg.maxAccessTokenTTL()); err != nil {
+            return err
+        }
+    }
+
+    // Tokens issued before access tokens carried a session.
+    return cfg.denylist.RevokeUser(ctx, userID, cfg.maxAccessTokenTTL())
+}
+
+// maxAccessTokenTTL is the longest any access token can be valid for, and
+// so how long a denylist entry has to be kept.
+func (cfg *apiConfig) maxAccessTokenTTL() time.Duration {
+    return max(cfg.authConfig.AccessTokenTTL, cfg.authConfig.ScopedTokenMaxTTL)
+}
+
+func (cfg *apiConfig) banUserHandler(w http.ResponseWriter, r *http.Request) {
+    userID, err := uuid.Parse(r.PathValue("userID"))
+    if err != nil {
+        http.Error(w, "Invalid user ID", http.StatusBadRequest)
+        return
+    }
+
+    if userID == userIDFromContext(r.Context()) {
+        http.Error(w, "Admins cannot ban themselves", http.StatusBadRequest)
+        return
+    }
+
+    _, err = cfg.DB.BanUser(r.Context(), userID)
+    if errors.Is(err, sql.ErrNoRows) {
+        http.Error(w, "User not found", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to ban user", http.StatusInternalServerError)
+        return
+    }
+
+    if err := cfg.revokeAllUserTokens(r.Context(), userID); err != nil {
+        http.Error(w, "Failed to revoke tokens", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unbanUserHandler(w http.ResponseWriter, r *http.Request) {
+    userID, err := uuid.Parse(r.PathValue("userID"))
+    if err != nil {
+        http.Error(w, "Invalid user ID", http.StatusBadRequest)
+        return
+    }
+
+    _, err = cfg.DB.UnbanUser(r.Context(), userID)
+    if errors.Is(err, sql.ErrNoRows) {
+        http.Error(w, "User not found", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to unban user", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "time"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/database"
+)
+
+type FollowEntry struct {
+    UserID     uuid.UUID `json:"user_id"`
+    FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowPage struct {
+    Users      []FollowEntry `json:"users"`
+    NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// pathUser resolves the {userID} path value to an existing user.
+func (cfg *apiConfig) pathUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+    userID, err := uuid.Parse(r.PathValue("userID"))
+    if err != nil {
+        http.Error(w, "Invalid user ID", http.StatusBadRequest)
+        return uuid.Nil, false
+    }
+
+    if _, err := cfg.DB.GetUserByID(r.Context(), userID); err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "User not found", http.StatusNotFound)
+            return uuid.Nil, false
+        }
+        http.Error(w, "Database error", http.StatusInternalServerError)
+        return uuid.Nil, false
+    }
+
+    return userID, true
+}
+
+func (cfg *apiConfig) followHandler(w http.ResponseWriter, r *http.Request) {
+    followerID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return
+    }
+
+    followeeID, ok := cfg.pathUser(w, r)
+    if !ok {
+        return
+    }
+
+    if followerID == followeeID {
+        http.Error(w, "Users cannot follow themselves", http.StatusBadRequest)
+        return
+    }
+
+    err := cfg.DB.FollowUser(r.Context(), database.FollowUserParams{
+        FollowerID: followerID,
+        FolloweeID: followeeID,
+    })
+    if err != nil {
+        http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unfollowHandler(w http.ResponseWriter, r *http.Request) {
+    followerID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return
+    }
+
+    followeeID, err := uuid.Parse(r.PathValue("userID"))
+    if err != nil {
+        http.Error(w, "Invalid user ID", http.StatusBadRequest)
+        return
+    }
+
+    err = cfg.DB.UnfollowUser(r.Context(), database.UnfollowUserParams{
+        FollowerID: followerID,
+        FolloweeID: followeeID,
+    })
+    if err != nil {
+        http.Error(w, "Failed to unfollow user", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) followersHandler(w http.ResponseWriter, r *http.Request) {
+    userID, ok := cfg.pathUser(w, r)
+    if !ok {
+        return
+    }
+
+    page, err := parsePageParams(r.URL.Query())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    cursorCreatedAt, cursorID := page.cursorArgs()
+    rows, err := cfg.DB.GetFollowers(r.Context(), database.GetFollowersParams{
+        UserID:          userID,
+        CursorCreatedAt: cursorCreatedAt,
+        CursorID:        cursorID,
+        PageSize:        page.Limit + 1,
+    })
+    if err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    entries := make([]FollowEntry, 0, len(rows))
+    for _, row := range rows {
+        entries = append(entries, FollowEntry{UserID: row.FollowerID, FollowedAt: row.CreatedAt})
+    }
+
+    writeFollowPage(w, entries, page.Limit)
+}
+
+func (cfg *apiConfig) followingHandler(w http.ResponseWriter, r *http.Request) {
+    userID, ok := cfg.pathUser(w, r)
+    if !ok {
+        return
+    }
+
+    page, err := parsePageParams(r.URL.Query())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    cursorCreatedAt, cursorID := page.cursorArgs()
+    rows, err := cfg.DB.GetFollowing(r.Context(), database.GetFollowingParams{
+        UserID:          userID,
+        CursorCreatedAt: cursorCreatedAt,
+        CursorID:        cursorID,
+        PageSize:        page.Limit + 1,
+    })
+    if err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    entries := make([]FollowEntry, 0, len(rows))
+    for _, row := range rows {
+        entries = append(entries, FollowEntry{UserID: row.FolloweeID, FollowedAt: row.CreatedAt})
+    }
+
+    writeFollowPage(w, entries, page.Limit)
+}
+
+func writeFollowPage(w http.ResponseWriter, entries []FollowEntry, limit int32) {
+    resp := FollowPage{Users: entries}
+
+    if len(entries) > int(limit) {
+        resp.Users = entries[:limit]
+        last := resp.Users[len(resp.Users)-1]
+        resp.NextCursor = encodeCursor(cursor{CreatedAt: last.FollowedAt, ID: last.UserID})
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(resp)
+}
+
+func (cfg *apiConfig) timelineHandler(w http.ResponseWriter, r *http.Request) {
+    userID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return
+    }
+
+    page, err := parsePageParams(r.URL.Query())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    cursorCreatedAt, cursorID := page.cursorArgs()
+    chirps, err := cfg.DB.GetTimeline(r.Context(), database.GetTimelineParams{
+        UserID:          userID,
+        CursorCreatedAt: cursorCreatedAt,
+        CursorID:        cursorID,
+        PageSize:        page.Limit + 1,
+    })
+    if err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    resp := newChirpPage(chirps, page.Limit)
+    if err := cfg.hydrateChirps(r.Context(), userID, resp.Chirps); err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/danon29/chippy/internal/database"
+    "github.com/danon29/chippy/internal/extract"
+)
+
+const (
+    defaultTrendingHours = 24
+    maxTrendingHours     = 24 * 7
+    defaultTrendingLimit = 10
+)
+
+type TrendingHashtag struct {
+    Tag   string `json:"tag"`
+    Count int64  `json:"count"`
+}
+
+// indexChirpEntities stores the hashtags and mentions found in the chirp
+// body, replacing any stored for a previous version. q is expected to run in
+// the same transaction that wrote the chirp.
+func indexChirpEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+    if err := q.DeleteChirpHashtags(ctx, chirp.ID); err != nil {
+        return err
+    }
+    if err := q.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+        return err
+    }
+
+    for _, tag := range extract.Hashtags(chirp.Body) {
+        hashtag, err := q.UpsertHashtag(ctx, tag)
+        if err != nil {
+            return err
+        }
+        err = q.AddChirpHashtag(ctx, database.AddChirpHashtagParams{
+            ChirpID:   chirp.ID,
+            HashtagID: hashtag.ID,
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    if mentions := extract.Mentions(chirp.Body); len(mentions) > 0 {
+        err := q.AddMentions(ctx, database.AddMentionsParams{
+            ChirpID: chirp.ID,
+            Emails:  mentions,
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+func (cfg *apiConfig) hashtagChirpsHandler(w http.ResponseWriter, r *http.Request) {
+    tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+    if tag == "" {
+        http.Error(w, "tag is required", http.StatusBadRequest)
+        return
+    }
+
+    page, err := parsePageParams(r.URL.Query())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    cursorCreatedAt, cursorID := page.cursorArgs()
+    chirps, err := cfg.DB.GetHashtagChirps(r.Context(), database.GetHashtagChirpsParams{
+        Tag:             tag,
+        CursorCreatedAt: cursorCreatedAt,
+        CursorID:        cursorID,
+        PageSize:        page.Limit + 1,
+    })
+    if err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    resp := newChirpPage(chirps, page.Limit)
+    if err := cfg.hydrateChirps(r.Context(), cfg.optionalUser(r), resp.Chirps); err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(resp)
+}
+
+func (cfg *apiConfig) trendingHashtagsHandler(w http.ResponseWriter, r *http.Request) {
+    hours := defaultTrendingHours
+    if hoursStr := r.URL.Query().Get("hours"); hoursStr != "" {
+        h, err := strconv.Atoi(hoursStr)
+        if err != nil || h < 1 || h > maxTrendingHours {
+            http.Error(w, "invalid hours", http.StatusBadRequest)
+            return
+        }
+        hours = h
+    }
+
+    limit := defaultTrendingLimit
+    if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+        l, err := strconv.Atoi(limitStr)
+        if err != nil || l < 1 || l > maxPageSize {
+            http.Error(w, "invalid limit", http.StatusBadRequest)
+            return
+        }
+        limit = l
+    }
+
+    rows, err := cfg.DB.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+        Since:    time.Now().Add(-time.Duration(hours) * time.Hour),
+        PageSize: int32(limit),
+    })
+    if err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    resp := make([]TrendingHashtag, 0, len(rows))
+    for _, row := range rows {
+        resp = append(resp, TrendingHashtag{Tag: row.Tag, Count: row.ChirpCount})
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(resp)
+}
+
+func (cfg *apiConfig) mentionsHandler(w http.ResponseWriter, r *http.Request) {
+    userID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return
+    }
+
+    page, err := parsePageParams(r.URL.Query())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    cursorCreatedAt, cursorID := page.cursorArgs()
+    chirps, err := cfg.DB.GetUserMentions(r.Context(), database.GetUserMentionsParams{
+        UserID:          userID,
+        CursorCreatedAt: cursorCreatedAt,
+        CursorID:        cursorID,
+        PageSize:        page.Limit + 1,
+    })
+    if err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    resp := newChirpPage(chirps, page.Limit)
+    if err := cfg.hydrateChirps(r.Context(), userID, resp.Chirps); err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(resp)
+}
@@ -4,8 +4,10 @@ import (
     "time"
 	"strings"
 	"errors"
+	"fmt"
     "net/http"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 
     "github.com/alexedwards/argon2id"
@@ -13,14 +15,48 @@ import (
     "github.com/golang-jwt/jwt/v5"
 )
 
-func HashPassword(password string) (string, error) {
-    hash, err := argon2id.CreateHash(password, argon2id.DefaultParams)
+// PasswordParams are the argon2id cost parameters for new password hashes.
+type PasswordParams struct {
+    MemoryKiB   uint32
+    Iterations  uint32
+    Parallelism uint8
+    SaltLength  uint32
+    KeyLength   uint32
+}
+
+func (p PasswordParams) argon2id() *argon2id.Params {
+    return &argon2id.Params{
+        Memory:      p.MemoryKiB,
+        Iterations:  p.Iterations,
+        Parallelism: p.Parallelism,
+        SaltLength:  p.SaltLength,
+        KeyLength:   p.KeyLength,
+    }
+}
+
+func HashPassword(password string, params PasswordParams) (string, error) {
+    hash, err := argon2id.CreateHash(password, params.argon2id())
     if err != nil {
         return "", err
     }
     return hash, nil
 }
 
+// NeedsRehash reports whether hash was made with parameters other than
+// params, so it should be replaced the next time the password is known.
+func NeedsRehash(hash string, params PasswordParams) (bool, error) {
+    stored, salt, key, err := argon2id.DecodeHash(hash)
+    if err != nil {
+        return false, err
+    }
+
+    return stored.Memory != params.MemoryKiB ||
+        stored.Iterations != params.Iterations ||
+        stored.Parallelism != params.Parallelism ||
+        uint32(len(salt)) != params.SaltLength ||
+        uint32(len(key)) != params.KeyLength, nil
+}
+
 func CheckPasswordHash(password, hash string) (bool, error) {
     match, err := argon2id.ComparePasswordAndHash(password, hash)
     if err != nil {
@@ -29,17 +65,117 @@ func CheckPasswordHash(password, hash string) (bool, error) {
     return match, nil
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+const (
+    RoleUser      = "user"
+    RoleModerator = "moderator"
+    RoleAdmin     = "admin"
+)
+
+var roleRank = map[string]int{
+    RoleUser:      1,
+    RoleModerator: 2,
+    RoleAdmin:     3,
+}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+    _, ok := roleRank[role]
+    return ok
+}
+
+// HasRole reports whether role grants at least the permissions of minRole.
+// Admins can do everything moderators can.
+func HasRole(role, minRole string) bool {
+    return roleRank[role] > 0 && roleRank[role] >= roleRank[minRole]
+}
+
+const (
+    ScopeChirpsWrite = "chirps:write"
+    ScopeUsersWrite  = "users:write"
+    ScopeAdmin       = "admin"
+)
+
+var knownScopes = map[string]bool{
+    ScopeChirpsWrite: true,
+    ScopeUsersWrite:  true,
+    ScopeAdmin:       true,
+}
+
+// ValidScope reports whether scope is one of the known scopes.
+func ValidScope(scope string) bool {
+    return knownScopes[scope]
+}
+
+// DefaultScopes returns the scopes granted to a full login session for role.
+func DefaultScopes(role string) []string {
+    scopes := []string{ScopeChirpsWrite, ScopeUsersWrite}
+    if HasRole(role, RoleModerator) {
+        scopes = append(scopes, ScopeAdmin)
+    }
+    return scopes
+}
+
+// Claims are the JWT claims issued by MakeJWT.
+type Claims struct {
+    Role   string   `json:"role,omitempty"`
+    Scopes []string `json:"scopes,omitempty"`
+    // SessionID is the login session the token was issued to, if any.
+    SessionID string `json:"sid,omitempty"`
+    // Scoped is set on tokens from MakeScopedJWT, as opposed to the ones a
+    // login session gets.
+    Scoped bool `json:"scoped,omitempty"`
+    jwt.RegisteredClaims
+}
+
+// HasScope reports whether the token was granted scope.
+func (c *Claims) HasScope(scope string) bool {
+    for _, s := range c.Scopes {
+        if s == scope {
+            return true
+        }
+    }
+    return false
+}
+
+// MakeJWT issues an access token carrying the default scopes for role.
+func MakeJWT(userID uuid.UUID, role string, keys *Keyring, expiresIn time.Duration) (string, error) {
+    return MakeSessionJWT(userID, uuid.Nil, role, keys, expiresIn)
+}
+
+// MakeSessionJWT is MakeJWT for a token issued to login session sessionID,
+// so that revoking the session also revokes the token.
+func MakeSessionJWT(userID, sessionID uuid.UUID, role string, keys *Keyring, expiresIn time.Duration) (string, error) {
+    return makeJWT(userID, sessionID, role, DefaultScopes(role), false, keys, expiresIn)
+}
+
+// MakeScopedJWT issues an access token limited to scopes. sessionID may be
+// uuid.Nil for a token that belongs to no session.
+func MakeScopedJWT(userID, sessionID uuid.UUID, role string, scopes []string, keys *Keyring, expiresIn time.Duration) (string, error) {
+    return makeJWT(userID, sessionID, role, scopes, true, keys, expiresIn)
+}
+
+func makeJWT(userID, sessionID uuid.UUID, role string, scopes []string, scoped bool, keys *Keyring, expiresIn time.Duration) (string, error) {
     now := time.Now().UTC()
 
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-        Issuer:    "chirpy",
-        IssuedAt:  jwt.NewNumericDate(now), 
-        ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)), 
-        Subject:   userID.String(), 
-    }) 
+    var sid string
+    if sessionID != uuid.Nil {
+        sid = sessionID.String()
+    }
 
-    signedToken, err := token.SignedString([]byte(tokenSecret))
+    signedToken, err := keys.sign(Claims{
+        Role:      role,
+        Scopes:    scopes,
+        SessionID: sid,
+        Scoped:    scoped,
+        RegisteredClaims: jwt.RegisteredClaims{
+            Issuer:    keys.Issuer,
+            Audience:  jwt.ClaimStrings{Audience},
+            IssuedAt:  jwt.NewNumericDate(now), 
+            ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)), 
+            Subject:   userID.String(), 
+            ID:        uuid.NewString(),
+        },
+    }) 
     if err != nil {
         return "", err  
     }
@@ -47,26 +183,123 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
     return signedToken, nil 
 }
 
-func ValidateJWT(tokenString string, tokenSecret string) (uuid.UUID, error) {
-    token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, 
-        func(token *jwt.Token) (interface{}, error) {
-            return []byte(tokenSecret), nil
-        })
+const (
+    DefaultIssuer = "chirpy"
+    Audience      = "chirpy-api"
+)
+
+var (
+    ErrMalformedToken   = errors.New("malformed token")
+    ErrBadSignature     = errors.New("invalid token signature")
+    ErrTokenExpired     = errors.New("token has expired")
+    ErrTokenNotYetValid = errors.New("token is not valid yet")
+    ErrWrongIssuer      = errors.New("token has the wrong issuer")
+    ErrWrongAudience    = errors.New("token has the wrong audience")
+    ErrMissingClaim     = errors.New("token is missing a required claim")
+    ErrInvalidToken     = errors.New("invalid token")
+)
+
+// ValidationOptions controls which tokens ValidateJWTWithOptions accepts.
+type ValidationOptions struct {
+    // Algorithms lists the accepted alg header values.
+    Algorithms []string
+    // Issuer defaults to the keyring's issuer when empty.
+    Issuer string
+    // Audience is skipped when empty.
+    Audience string
+    // Leeway is the clock skew tolerated on exp, nbf and iat.
+    Leeway          time.Duration
+    RequireExpiry   bool
+    RequireIssuedAt bool
+}
+
+// DefaultValidationOptions accepts tokens minted by MakeJWT.
+var DefaultValidationOptions = ValidationOptions{
+    Algorithms:      []string{"EdDSA", "RS256", "HS256"},
+    Audience:        Audience,
+    Leeway:          30 * time.Second,
+    RequireExpiry:   true,
+    RequireIssuedAt: true,
+}
+
+func ValidateJWT(tokenString string, keys *Keyring) (uuid.UUID, error) {
+    claims, err := ValidateJWTWithClaims(tokenString, keys)
     if err != nil {
         return uuid.Nil, err
     }
+    return uuid.Parse(claims.Subject)
+}
+
+// ValidateJWTWithClaims is ValidateJWT that returns the full claim set. The
+// subject is guaranteed to be a valid user ID.
+func ValidateJWTWithClaims(tokenString string, keys *Keyring) (*Claims, error) {
+    return ValidateJWTWithOptions(tokenString, keys, DefaultValidationOptions)
+}
+
+// ValidateJWTWithOptions validates a token against opts. Errors wrap one of
+// the Err* sentinels so callers can report why a token was refused.
+func ValidateJWTWithOptions(tokenString string, keys *Keyring, opts ValidationOptions) (*Claims, error) {
+    issuer := opts.Issuer
+    if issuer == "" {
+        issuer = keys.Issuer
+    }
+
+    parserOpts := []jwt.ParserOption{
+        jwt.WithValidMethods(opts.Algorithms),
+        jwt.WithIssuer(issuer),
+        jwt.WithLeeway(opts.Leeway),
+        jwt.WithIssuedAt(),
+    }
+    if opts.Audience != "" {
+        parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+    }
+    if opts.RequireExpiry {
+        parserOpts = append(parserOpts, jwt.WithExpirationRequired())
+    }
+
+    token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.keyfunc, parserOpts...)
+    if err != nil {
+        return nil, classifyJWTError(err)
+    }
     
     if !token.Valid {
-        return uuid.Nil, jwt.ErrTokenInvalidClaims
+        return nil, ErrInvalidToken
     }
     
-    claims := token.Claims.(*jwt.RegisteredClaims)
-    userID, err := uuid.Parse(claims.Subject)
-    if err != nil {
-        return uuid.Nil, err
+    claims := token.Claims.(*Claims)
+    if opts.RequireIssuedAt && claims.IssuedAt == nil {
+        return nil, fmt.Errorf("%w: iat", ErrMissingClaim)
+    }
+    if _, err := uuid.Parse(claims.Subject); err != nil {
+        return nil, fmt.Errorf("%w: sub is not a user ID", ErrInvalidToken)
     }
     
-    return userID, nil
+    return claims, nil
+}
+
+// classifyJWTError maps a jwt parse error onto our sentinels, keeping the
+// original error in the chain.
+func classifyJWTError(err error) error {
+    var sentinel error
+    switch {
+    case errors.Is(err, jwt.ErrTokenMalformed):
+        sentinel = ErrMalformedToken
+    case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+        sentinel = ErrBadSignature
+    case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+        sentinel = ErrMissingClaim
+    case errors.Is(err, jwt.ErrTokenExpired):
+        sentinel = ErrTokenExpired
+    case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+        sentinel = ErrTokenNotYetValid
+    case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+        sentinel = ErrWrongIssuer
+    case errors.Is(err, jwt.ErrTokenInvalidAudience):
+        sentinel = ErrWrongAudience
+    default:
+        sentinel = ErrInvalidToken
+    }
+    return fmt.Errorf("%w: %w", sentinel, err)
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -107,4 +340,12 @@ func MakeRefreshToken() (string, error) {
 
 	encodedString := hex.EncodeToString(key)
 	return encodedString, nil
-}
\ No newline at end of file
+}
+
+// HashRefreshToken returns the digest refresh tokens are stored under, so a
+// leaked table does not hand out live sessions. Tokens carry 256 bits of
+// entropy, so a plain SHA-256 needs no salt.
+func HashRefreshToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
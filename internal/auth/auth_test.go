@@ -4,25 +4,345 @@ import (
     "testing"
     "time"
     
+    "github.com/golang-jwt/jwt/v5"
     "github.com/google/uuid"
     "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
 )
 
+var testPasswordParams = PasswordParams{MemoryKiB: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHashPassword(t *testing.T) {
+    hash, err := HashPassword("correct horse", testPasswordParams)
+    require.NoError(t, err)
+    assert.Contains(t, hash, "$m=1024,t=1,p=1$")
+
+    ok, err := CheckPasswordHash("correct horse", hash)
+    require.NoError(t, err)
+    assert.True(t, ok)
+
+    ok, err = CheckPasswordHash("wrong horse", hash)
+    require.NoError(t, err)
+    assert.False(t, ok)
+}
+
+func TestNeedsRehash(t *testing.T) {
+    hash, err := HashPassword("correct horse", testPasswordParams)
+    require.NoError(t, err)
+
+    stronger := testPasswordParams
+    stronger.Iterations = 2
+    longerKey := testPasswordParams
+    longerKey.KeyLength = 64
+
+    tests := []struct {
+        name   string
+        params PasswordParams
+        want   bool
+    }{
+        {"same parameters", testPasswordParams, false},
+        {"more iterations", stronger, true},
+        {"longer key", longerKey, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := NeedsRehash(hash, tt.params)
+            require.NoError(t, err)
+            assert.Equal(t, tt.want, got)
+        })
+    }
+
+    _, err = NeedsRehash("not-a-hash", testPasswordParams)
+    assert.Error(t, err)
+}
+
 func TestMakeJWT_ValidateJWT(t *testing.T) {
-    secret := "test-secret"
+    keys := NewHMACKeyring("test-secret")
     userID := uuid.New()
     expiresIn := time.Minute
     
-    token, err := MakeJWT(userID, secret, expiresIn)
+    token, err := MakeJWT(userID, RoleUser, keys, expiresIn)
     assert.NoError(t, err)
     assert.NotEmpty(t, token)
     
-    validatedID, err := ValidateJWT(token, secret)
+    validatedID, err := ValidateJWT(token, keys)
     assert.NoError(t, err)
     assert.Equal(t, userID, validatedID)
 }
 
 func TestValidateJWT_InvalidToken(t *testing.T) {
-    _, err := ValidateJWT("invalid.token", "secret")
+    _, err := ValidateJWT("invalid.token", NewHMACKeyring("secret"))
     assert.Error(t, err)
 }
+
+func TestValidateJWTWithClaims(t *testing.T) {
+    userID := uuid.New()
+
+    token, err := MakeJWT(userID, RoleModerator, NewHMACKeyring("test-secret"), time.Minute)
+    assert.NoError(t, err)
+
+    claims, err := ValidateJWTWithClaims(token, NewHMACKeyring("test-secret"))
+    assert.NoError(t, err)
+    assert.Equal(t, userID.String(), claims.Subject)
+    assert.Equal(t, RoleModerator, claims.Role)
+    assert.NotEmpty(t, claims.ID)
+    assert.True(t, claims.HasScope(ScopeChirpsWrite))
+    assert.True(t, claims.HasScope(ScopeAdmin))
+}
+
+func TestMakeScopedJWT(t *testing.T) {
+    userID := uuid.New()
+
+    token, err := MakeScopedJWT(userID, uuid.Nil, RoleAdmin, []string{ScopeChirpsWrite}, NewHMACKeyring("test-secret"), time.Minute)
+    assert.NoError(t, err)
+
+    claims, err := ValidateJWTWithClaims(token, NewHMACKeyring("test-secret"))
+    assert.NoError(t, err)
+    assert.Empty(t, claims.SessionID)
+    assert.True(t, claims.Scoped)
+    assert.True(t, claims.HasScope(ScopeChirpsWrite))
+    assert.False(t, claims.HasScope(ScopeUsersWrite))
+    assert.False(t, claims.HasScope(ScopeAdmin))
+}
+
+func TestMakeSessionJWT(t *testing.T) {
+    sessionID := uuid.New()
+
+    token, err := MakeSessionJWT(uuid.New(), sessionID, RoleUser, NewHMACKeyring("test-secret"), time.Minute)
+    assert.NoError(t, err)
+
+    claims, err := ValidateJWTWithClaims(token, NewHMACKeyring("test-secret"))
+    assert.NoError(t, err)
+    assert.Equal(t, sessionID.String(), claims.SessionID)
+    assert.False(t, claims.Scoped)
+    assert.True(t, claims.HasScope(ScopeUsersWrite))
+}
+
+func TestDefaultScopes(t *testing.T) {
+    assert.ElementsMatch(t, []string{ScopeChirpsWrite, ScopeUsersWrite}, DefaultScopes(RoleUser))
+    assert.Contains(t, DefaultScopes(RoleModerator), ScopeAdmin)
+    assert.Contains(t, DefaultScopes(RoleAdmin), ScopeAdmin)
+}
+
+func TestHasRole(t *testing.T) {
+    assert.True(t, HasRole(RoleAdmin, RoleModerator))
+    assert.True(t, HasRole(RoleModerator, RoleModerator))
+    assert.False(t, HasRole(RoleUser, RoleModerator))
+    assert.False(t, HasRole("", RoleUser))
+    assert.False(t, HasRole("root", RoleUser))
+}
+
+func TestValidateJWT_Errors(t *testing.T) {
+    keys := NewHMACKeyring("test-secret")
+    now := time.Now()
+    subject := uuid.New().String()
+
+    validClaims := func() jwt.RegisteredClaims {
+        return jwt.RegisteredClaims{
+            Issuer:    DefaultIssuer,
+            Audience:  jwt.ClaimStrings{Audience},
+            Subject:   subject,
+            IssuedAt:  jwt.NewNumericDate(now),
+            ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+        }
+    }
+
+    sign := func(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, rc jwt.RegisteredClaims) string {
+        t.Helper()
+        token := jwt.NewWithClaims(method, Claims{RegisteredClaims: rc})
+        token.Header["kid"] = kid
+        signed, err := token.SignedString(key)
+        require.NoError(t, err)
+        return signed
+    }
+
+    tests := []struct {
+        name    string
+        token   func(t *testing.T) string
+        wantErr error
+    }{
+        {
+            name: "valid",
+            token: func(t *testing.T) string {
+                return sign(t, jwt.SigningMethodHS256, []byte("test-secret"), "hs256", validClaims())
+            },
+        },
+        {
+            name: "expired within leeway",
+            token: func(t *testing.T) string {
+                rc := validClaims()
+                rc.ExpiresAt = jwt.NewNumericDate(now.Add(-10 * time.Second))
+                return sign(t, jwt.SigningMethodHS256, []byte("test-secret"), "hs256", rc)
+            },
+        },
+        {
+            name: "expired",
+            token: func(t *testing.T) string {
+                rc := validClaims()
+                rc.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Hour))
+                return sign(t, jwt.SigningMethodHS256, []byte("test-secret"), "hs256", rc)
+            },
+            wantErr: ErrTokenExpired,
+        },
+        {
+            name: "not valid yet",
+            token: func(t *testing.T) string {
+                rc := validClaims()
+                rc.NotBefore = jwt.NewNumericDate(now.Add(time.Hour))
+                return sign(t, jwt.SigningMethodHS256, []byte("test-secret"), "hs256", rc)
+            },
+            wantErr: ErrTokenNotYetValid,
+        },
+        {
+            name: "issued in the future",
+            token: func(t *testing.T) string {
+                rc := validClaims()
+                rc.IssuedAt = jwt.NewNumericDate(now.Add(time.Hour))
+                return sign(t, jwt.SigningMethodHS256, []byte("test-secret"), "hs256", rc)
+            },
+            wantErr: ErrTokenNotYetValid,
+        },
+        {
+            name: "wrong issuer",
+            token: func(t *testing.T) string {
+                rc := validClaims()
+                rc.Issuer = "someone-else"
+                return sign(t, jwt.SigningMethodHS256, []byte("test-secret"), "hs256", rc)
+            },
+            wantErr: ErrWrongIssuer,
+        },
+        {
+            name: "wrong audience",
+            token: func(t *testing.T) string {
+                rc := validClaims()
+                rc.Audience = jwt.ClaimStrings{"another-api"}
+                return sign(t, jwt.SigningMethodHS256, []byte("test-secret"), "hs256", rc)
+            },
+            wantErr: ErrWrongAudience,
+        },
+        {
+            name: "missing exp",
+            token: func(t *testing.T) string {
+                rc := validClaims()
+                rc.ExpiresAt = nil
+                return sign(t, jwt.SigningMethodHS256, []byte("test-secret"), "hs256", rc)
+            },
+            wantErr: ErrMissingClaim,
+        },
+        {
+            name: "missing iat",
+            token: func(t *testing.T) string {
+                rc := validClaims()
+                rc.IssuedAt = nil
+                return sign(t, jwt.SigningMethodHS256, []byte("test-secret"), "hs256", rc)
+            },
+            wantErr: ErrMissingClaim,
+        },
+        {
+            name: "wrong secret",
+            token: func(t *testing.T) string {
+                return sign(t, jwt.SigningMethodHS256, []byte("other-secret"), "hs256", validClaims())
+            },
+            wantErr: ErrBadSignature,
+        },
+        {
+            name: "algorithm outside the allowed list",
+            token: func(t *testing.T) string {
+                return sign(t, jwt.SigningMethodHS512, []byte("test-secret"), "hs256", validClaims())
+            },
+            wantErr: ErrBadSignature,
+        },
+        {
+            name: "alg none",
+            token: func(t *testing.T) string {
+                return sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "hs256", validClaims())
+            },
+            wantErr: ErrBadSignature,
+        },
+        {
+            name: "unknown kid",
+            token: func(t *testing.T) string {
+                return sign(t, jwt.SigningMethodHS256, []byte("test-secret"), "retired", validClaims())
+            },
+            wantErr: ErrBadSignature,
+        },
+        {
+            name: "subject is not a user ID",
+            token: func(t *testing.T) string {
+                rc := validClaims()
+                rc.Subject = "not-a-uuid"
+                return sign(t, jwt.SigningMethodHS256, []byte("test-secret"), "hs256", rc)
+            },
+            wantErr: ErrInvalidToken,
+        },
+        {
+            name: "malformed",
+            token: func(t *testing.T) string {
+                return "not.a.jwt"
+            },
+            wantErr: ErrMalformedToken,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            claims, err := ValidateJWTWithClaims(tt.token(t), keys)
+            if tt.wantErr == nil {
+                require.NoError(t, err)
+                assert.Equal(t, subject, claims.Subject)
+                return
+            }
+            assert.ErrorIs(t, err, tt.wantErr)
+            assert.Nil(t, claims)
+        })
+    }
+}
+
+func TestValidateJWTWithOptions_AudienceOptional(t *testing.T) {
+    keys := NewHMACKeyring("test-secret")
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{RegisteredClaims: jwt.RegisteredClaims{
+        Issuer:    DefaultIssuer,
+        Subject:   uuid.New().String(),
+        IssuedAt:  jwt.NewNumericDate(time.Now()),
+        ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+    }})
+    token.Header["kid"] = "hs256"
+    signed, err := token.SignedString([]byte("test-secret"))
+    require.NoError(t, err)
+
+    _, err = ValidateJWTWithClaims(signed, keys)
+    assert.ErrorIs(t, err, ErrMissingClaim)
+
+    opts := DefaultValidationOptions
+    opts.Audience = ""
+    _, err = ValidateJWTWithOptions(signed, keys, opts)
+    assert.NoError(t, err)
+}
+
+func TestHashRefreshToken(t *testing.T) {
+    token, err := MakeRefreshToken()
+    require.NoError(t, err)
+
+    hash := HashRefreshToken(token)
+    assert.Len(t, hash, 64)
+    assert.NotEqual(t, token, hash)
+    assert.Equal(t, hash, HashRefreshToken(token))
+
+    // Matches Postgres' encode(sha256(convert_to(token, 'UTF8')), 'hex').
+    assert.Equal(t, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", HashRefreshToken("test"))
+}
+
+func TestValidateJWT_KeyringIssuer(t *testing.T) {
+    keys := NewHMACKeyring("test-secret")
+    keys.Issuer = "chirpy-staging"
+
+    token, err := MakeJWT(uuid.New(), RoleUser, keys, time.Minute)
+    require.NoError(t, err)
+
+    _, err = ValidateJWT(token, keys)
+    assert.NoError(t, err)
+
+    _, err = ValidateJWT(token, NewHMACKeyring("test-secret"))
+    assert.ErrorIs(t, err, ErrWrongIssuer)
+}
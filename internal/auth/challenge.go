@@ -0,0 +1,52 @@
+package auth
+
+import (
+    "fmt"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/google/uuid"
+)
+
+// ChallengeAudience marks tokens that only prove the password step of a
+// two-factor login. They grant no API access.
+const ChallengeAudience = "chirpy-2fa-challenge"
+
+// MakeChallengeToken issues a token saying userID has passed the password
+// check and still owes a second factor.
+func MakeChallengeToken(userID uuid.UUID, keys *Keyring, expiresIn time.Duration) (string, error) {
+    now := time.Now().UTC()
+    return keys.sign(jwt.RegisteredClaims{
+        Issuer:    keys.Issuer,
+        Audience:  jwt.ClaimStrings{ChallengeAudience},
+        IssuedAt:  jwt.NewNumericDate(now),
+        ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+        Subject:   userID.String(),
+        ID:        uuid.NewString(),
+    })
+}
+
+// ValidateChallengeToken checks a token made by MakeChallengeToken and
+// returns its user ID. Errors wrap the same sentinels as
+// ValidateJWTWithOptions.
+func ValidateChallengeToken(tokenString string, keys *Keyring) (uuid.UUID, error) {
+    token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, keys.keyfunc,
+        jwt.WithValidMethods(DefaultValidationOptions.Algorithms),
+        jwt.WithIssuer(keys.Issuer),
+        jwt.WithAudience(ChallengeAudience),
+        jwt.WithLeeway(DefaultValidationOptions.Leeway),
+        jwt.WithExpirationRequired(),
+    )
+    if err != nil {
+        return uuid.Nil, classifyJWTError(err)
+    }
+    if !token.Valid {
+        return uuid.Nil, ErrInvalidToken
+    }
+
+    userID, err := uuid.Parse(token.Claims.(*jwt.RegisteredClaims).Subject)
+    if err != nil {
+        return uuid.Nil, fmt.Errorf("%w: sub is not a user ID", ErrInvalidToken)
+    }
+    return userID, nil
+}
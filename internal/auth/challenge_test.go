@@ -0,0 +1,35 @@
+package auth
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestChallengeToken(t *testing.T) {
+    keys := NewHMACKeyring("secret")
+    userID := uuid.New()
+
+    token, err := MakeChallengeToken(userID, keys, 5*time.Minute)
+    require.NoError(t, err)
+
+    got, err := ValidateChallengeToken(token, keys)
+    require.NoError(t, err)
+    assert.Equal(t, userID, got)
+
+    _, err = ValidateJWT(token, keys)
+    assert.ErrorIs(t, err, ErrWrongAudience, "a challenge is not an access token")
+
+    access, err := MakeJWT(userID, RoleUser, keys, time.Hour)
+    require.NoError(t, err)
+    _, err = ValidateChallengeToken(access, keys)
+    assert.ErrorIs(t, err, ErrWrongAudience)
+
+    expired, err := MakeChallengeToken(userID, keys, -time.Minute)
+    require.NoError(t, err)
+    _, err = ValidateChallengeToken(expired, keys)
+    assert.ErrorIs(t, err, ErrTokenExpired)
+}
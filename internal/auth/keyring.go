@@ -0,0 +1,180 @@
+package auth
+
+import (
+    "crypto/ed25519"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "math/big"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// signingKey is one key in a Keyring. For asymmetric keys private is the
+// signer and public what verifiers use; for HMAC both are the secret.
+type signingKey struct {
+    id      string
+    method  jwt.SigningMethod
+    private interface{}
+    public  interface{}
+}
+
+// Keyring holds the key new tokens are signed with plus older keys that are
+// still accepted for verification, so rotating keys does not log anyone out.
+type Keyring struct {
+    // Issuer is the iss claim set on and required of tokens.
+    Issuer string
+
+    current *signingKey
+    keys    map[string]*signingKey
+}
+
+// NewHMACKeyring returns a keyring that signs with HS256 using secret. It is
+// meant for local development; its key is never published in the JWKS.
+func NewHMACKeyring(secret string) *Keyring {
+    key := &signingKey{
+        id:      "hs256",
+        method:  jwt.SigningMethodHS256,
+        private: []byte(secret),
+        public:  []byte(secret),
+    }
+    return &Keyring{Issuer: DefaultIssuer, current: key, keys: map[string]*signingKey{key.id: key}}
+}
+
+// LoadKeyring reads every *.pem private key in dir. RSA keys sign with RS256
+// and Ed25519 keys with EdDSA. The file name without its extension is the
+// key ID; the key whose name sorts last signs new tokens, so naming files by
+// date (2026-10-01.pem) makes rotation a matter of adding a file.
+func LoadKeyring(dir string) (*Keyring, error) {
+    paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+    if err != nil {
+        return nil, err
+    }
+    if len(paths) == 0 {
+        return nil, fmt.Errorf("no *.pem keys in %s", dir)
+    }
+    sort.Strings(paths)
+
+    ring := &Keyring{Issuer: DefaultIssuer, keys: make(map[string]*signingKey, len(paths))}
+    for _, path := range paths {
+        key, err := loadKey(path)
+        if err != nil {
+            return nil, fmt.Errorf("%s: %w", path, err)
+        }
+        ring.keys[key.id] = key
+        ring.current = key
+    }
+    return ring, nil
+}
+
+func loadKey(path string) (*signingKey, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+
+    block, _ := pem.Decode(data)
+    if block == nil {
+        return nil, errors.New("no PEM block found")
+    }
+
+    var parsed interface{}
+    switch block.Type {
+    case "RSA PRIVATE KEY":
+        parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+    case "PRIVATE KEY":
+        parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+    default:
+        return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+    switch k := parsed.(type) {
+    case *rsa.PrivateKey:
+        if k.N.BitLen() < 2048 {
+            return nil, errors.New("RSA keys must be at least 2048 bits")
+        }
+        return &signingKey{id: id, method: jwt.SigningMethodRS256, private: k, public: &k.PublicKey}, nil
+    case ed25519.PrivateKey:
+        return &signingKey{id: id, method: jwt.SigningMethodEdDSA, private: k, public: k.Public()}, nil
+    default:
+        return nil, fmt.Errorf("unsupported key type %T", parsed)
+    }
+}
+
+// CurrentKeyID returns the ID of the key new tokens are signed with.
+func (k *Keyring) CurrentKeyID() string {
+    return k.current.id
+}
+
+// sign signs claims with the current key and records its ID in the kid header.
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+    token := jwt.NewWithClaims(k.current.method, claims)
+    token.Header["kid"] = k.current.id
+    return token.SignedString(k.current.private)
+}
+
+// keyfunc picks the verification key named by the token's kid header and
+// refuses tokens whose alg does not match that key.
+func (k *Keyring) keyfunc(token *jwt.Token) (interface{}, error) {
+    kid, _ := token.Header["kid"].(string)
+    key, ok := k.keys[kid]
+    if !ok {
+        return nil, ErrUnknownKey
+    }
+    if token.Method.Alg() != key.method.Alg() {
+        return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+    }
+    return key.public, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid"`
+    Use string `json:"use"`
+    Alg string `json:"alg"`
+    N   string `json:"n,omitempty"`
+    E   string `json:"e,omitempty"`
+    Crv string `json:"crv,omitempty"`
+    X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+    Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every asymmetric key, sorted by ID.
+func (k *Keyring) JWKS() JWKSet {
+    set := JWKSet{Keys: []JWK{}}
+    for _, key := range k.keys {
+        jwk := JWK{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+        switch pub := key.public.(type) {
+        case *rsa.PublicKey:
+            jwk.Kty = "RSA"
+            jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+            jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+        case ed25519.PublicKey:
+            jwk.Kty = "OKP"
+            jwk.Crv = "Ed25519"
+            jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+        default:
+            continue
+        }
+        set.Keys = append(set.Keys, jwk)
+    }
+    sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+    return set
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+    "crypto/ed25519"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/pem"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/google/uuid"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func writeKey(t *testing.T, dir, name string, key interface{}) {
+    t.Helper()
+    der, err := x509.MarshalPKCS8PrivateKey(key)
+    require.NoError(t, err)
+    data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+    require.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"), data, 0o600))
+}
+
+func TestLoadKeyring_Rotation(t *testing.T) {
+    dir := t.TempDir()
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    require.NoError(t, err)
+    writeKey(t, dir, "2026-01-01", rsaKey)
+
+    oldRing, err := LoadKeyring(dir)
+    require.NoError(t, err)
+    assert.Equal(t, "2026-01-01", oldRing.CurrentKeyID())
+
+    userID := uuid.New()
+    oldToken, err := MakeJWT(userID, RoleUser, oldRing, time.Minute)
+    require.NoError(t, err)
+
+    _, edKey, err := ed25519.GenerateKey(rand.Reader)
+    require.NoError(t, err)
+    writeKey(t, dir, "2026-02-01", edKey)
+
+    newRing, err := LoadKeyring(dir)
+    require.NoError(t, err)
+    assert.Equal(t, "2026-02-01", newRing.CurrentKeyID())
+
+    // Tokens signed before the rotation still validate.
+    validatedID, err := ValidateJWT(oldToken, newRing)
+    require.NoError(t, err)
+    assert.Equal(t, userID, validatedID)
+
+    newToken, err := MakeJWT(userID, RoleUser, newRing, time.Minute)
+    require.NoError(t, err)
+    parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+    require.NoError(t, err)
+    assert.Equal(t, "EdDSA", parsed.Method.Alg())
+    assert.Equal(t, "2026-02-01", parsed.Header["kid"])
+
+    // The old ring does not know the new key.
+    _, err = ValidateJWT(newToken, oldRing)
+    assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestLoadKeyring_NoKeys(t *testing.T) {
+    _, err := LoadKeyring(t.TempDir())
+    assert.Error(t, err)
+}
+
+func TestKeyring_RejectsAlgorithmSwap(t *testing.T) {
+    dir := t.TempDir()
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    require.NoError(t, err)
+    writeKey(t, dir, "main", rsaKey)
+
+    ring, err := LoadKeyring(dir)
+    require.NoError(t, err)
+
+    // An HS256 token keyed with the public key must not verify.
+    pubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+    require.NoError(t, err)
+    forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+        RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.New().String()},
+    })
+    forged.Header["kid"] = "main"
+    token, err := forged.SignedString(pubDER)
+    require.NoError(t, err)
+
+    _, err = ValidateJWT(token, ring)
+    assert.Error(t, err)
+}
+
+func TestKeyring_JWKS(t *testing.T) {
+    dir := t.TempDir()
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    require.NoError(t, err)
+    writeKey(t, dir, "a", rsaKey)
+    _, edKey, err := ed25519.GenerateKey(rand.Reader)
+    require.NoError(t, err)
+    writeKey(t, dir, "b", edKey)
+
+    ring, err := LoadKeyring(dir)
+    require.NoError(t, err)
+
+    set := ring.JWKS()
+    require.Len(t, set.Keys, 2)
+    assert.Equal(t, JWK{Kty: "RSA", Kid: "a", Use: "sig", Alg: "RS256", N: set.Keys[0].N, E: "AQAB"}, set.Keys[0])
+    assert.Equal(t, "OKP", set.Keys[1].Kty)
+    assert.Equal(t, "Ed25519", set.Keys[1].Crv)
+    assert.Equal(t, "EdDSA", set.Keys[1].Alg)
+
+    assert.Empty(t, NewHMACKeyring("secret").JWKS().Keys)
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+    "fmt"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/google/uuid"
+)
+
+// EmailVerificationAudience keeps verification tokens and access tokens from
+// being accepted in place of each other.
+const EmailVerificationAudience = "chirpy-email-verification"
+
+// EmailVerificationClaims are the claims of a token proving control of Email.
+// The jti lets the server make the token single-use.
+type EmailVerificationClaims struct {
+    Email string `json:"email"`
+    jwt.RegisteredClaims
+}
+
+// MakeEmailVerificationToken issues a token for userID to verify email.
+func MakeEmailVerificationToken(userID uuid.UUID, email string, keys *Keyring, expiresIn time.Duration) (string, *EmailVerificationClaims, error) {
+    now := time.Now().UTC()
+    claims := &EmailVerificationClaims{
+        Email: email,
+        RegisteredClaims: jwt.RegisteredClaims{
+            Issuer:    keys.Issuer,
+            Audience:  jwt.ClaimStrings{EmailVerificationAudience},
+            IssuedAt:  jwt.NewNumericDate(now),
+            ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+            Subject:   userID.String(),
+            ID:        uuid.NewString(),
+        },
+    }
+
+    token, err := keys.sign(claims)
+    if err != nil {
+        return "", nil, err
+    }
+    return token, claims, nil
+}
+
+// ValidateEmailVerificationToken checks a token made by
+// MakeEmailVerificationToken. It does not check whether the token was
+// already used. Errors wrap the same sentinels as ValidateJWTWithOptions.
+func ValidateEmailVerificationToken(tokenString string, keys *Keyring) (*EmailVerificationClaims, error) {
+    token, err := jwt.ParseWithClaims(tokenString, &EmailVerificationClaims{}, keys.keyfunc,
+        jwt.WithValidMethods(DefaultValidationOptions.Algorithms),
+        jwt.WithIssuer(keys.Issuer),
+        jwt.WithAudience(EmailVerificationAudience),
+        jwt.WithLeeway(DefaultValidationOptions.Leeway),
+        jwt.WithExpirationRequired(),
+    )
+    if err != nil {
+        return nil, classifyJWTError(err)
+    }
+    if !token.Valid {
+        return nil, ErrInvalidToken
+    }
+
+    claims := token.Claims.(*EmailVerificationClaims)
+    if _, err := uuid.Parse(claims.Subject); err != nil {
+        return nil, fmt.Errorf("%w: sub is not a user ID", ErrInvalidToken)
+    }
+    if claims.Email == "" || claims.ID == "" {
+        return nil, fmt.Errorf("%w: email and jti", ErrMissingClaim)
+    }
+    return claims, nil
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestEmailVerificationToken(t *testing.T) {
+    keys := NewHMACKeyring("secret")
+    userID := uuid.New()
+
+    token, made, err := MakeEmailVerificationToken(userID, "jo@example.com", keys, time.Hour)
+    require.NoError(t, err)
+
+    claims, err := ValidateEmailVerificationToken(token, keys)
+    require.NoError(t, err)
+    assert.Equal(t, userID.String(), claims.Subject)
+    assert.Equal(t, "jo@example.com", claims.Email)
+    assert.Equal(t, made.ID, claims.ID)
+}
+
+func TestEmailVerificationToken_NotInterchangeable(t *testing.T) {
+    keys := NewHMACKeyring("secret")
+    userID := uuid.New()
+
+    verification, _, err := MakeEmailVerificationToken(userID, "jo@example.com", keys, time.Hour)
+    require.NoError(t, err)
+    _, err = ValidateJWT(verification, keys)
+    assert.ErrorIs(t, err, ErrWrongAudience)
+
+    access, err := MakeJWT(userID, RoleUser, keys, time.Hour)
+    require.NoError(t, err)
+    _, err = ValidateEmailVerificationToken(access, keys)
+    assert.ErrorIs(t, err, ErrWrongAudience)
+}
+
+func TestEmailVerificationToken_Expired(t *testing.T) {
+    keys := NewHMACKeyring("secret")
+
+    token, _, err := MakeEmailVerificationToken(uuid.New(), "jo@example.com", keys, -time.Hour)
+    require.NoError(t, err)
+    _, err = ValidateEmailVerificationToken(token, keys)
+    assert.ErrorIs(t, err, ErrTokenExpired)
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "net/mail"
+    "net/url"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3"
+)
+
+// Config is the server configuration. Values come from, in increasing order
+// of precedence: the defaults in Default, the YAML file named by CONFIG_FILE,
+// and environment variables (which .env may populate).
+type Config struct {
+    Platform            string   `yaml:"platform"`
+    DatabaseURL         string   `yaml:"database_url"`
+    PolkaKey            string   `yaml:"polka_key"`
+    ModerationWordsFile string   `yaml:"moderation_words_file"`
+    AllowedOrigins      []string `yaml:"allowed_origins"`
+    Auth                Auth     `yaml:"auth"`
+    Mail                Mail     `yaml:"mail"`
+}
+
+type Auth struct {
+    JWTSecret         string        `yaml:"jwt_secret"`
+    JWTKeysDir        string        `yaml:"jwt_keys_dir"`
+    Issuer            string        `yaml:"issuer"`
+    AccessTokenTTL    time.Duration `yaml:"access_token_ttl"`
+    RefreshTokenTTL   time.Duration `yaml:"refresh_token_ttl"`
+    ScopedTokenMaxTTL time.Duration `yaml:"scoped_token_max_ttl"`
+    // MaxFailedLogins consecutive wrong passwords lock an account for
+    // LockoutDuration.
+    MaxFailedLogins int           `yaml:"max_failed_logins"`
+    LockoutDuration time.Duration `yaml:"lockout_duration"`
+    // EmailVerificationTTL is how long a verification link stays valid.
+    EmailVerificationTTL time.Duration `yaml:"email_verification_ttl"`
+    PasswordResetTTL     time.Duration `yaml:"password_reset_ttl"`
+    PasswordMinLength    int           `yaml:"password_min_length"`
+    PasswordMaxLength    int           `yaml:"password_max_length"`
+    // BreachedPasswordsFile optionally names a list of SHA-1 hashes of
+    // breached passwords, which users may then not choose.
+    BreachedPasswordsFile string `yaml:"breached_passwords_file"`
+    // TOTPEncryptionKey is the base64 AES-256 key that two-factor secrets
+    // are encrypted with in the database. Without it two-factor
+    // authentication is unavailable.
+    TOTPEncryptionKey string `yaml:"totp_encryption_key"`
+    Argon2               Argon2        `yaml:"argon2"`
+}
+
+// TOTPKey decodes TOTPEncryptionKey.
+func (a Auth) TOTPKey() ([]byte, error) {
+    key, err := base64.StdEncoding.DecodeString(a.TOTPEncryptionKey)
+    if err != nil || len(key) != 32 {
+        return nil, errors.New("must be 32 bytes encoded as base64, e.g. from openssl rand -base64 32")
+    }
+    return key, nil
+}
+
+// Argon2 holds the argon2id cost parameters for new password hashes.
+type Argon2 struct {
+    MemoryKiB   uint32 `yaml:"memory_kib"`
+    Iterations  uint32 `yaml:"iterations"`
+    Parallelism uint8  `yaml:"parallelism"`
+    SaltLength  uint32 `yaml:"salt_length"`
+    KeyLength   uint32 `yaml:"key_length"`
+}
+
+// Mail configures outgoing email. Transport is "log" (the default), "file"
+// (one .eml file per message in Dir) or "smtp".
+type Mail struct {
+    Transport    string `yaml:"transport"`
+    Dir          string `yaml:"dir"`
+    From         string `yaml:"from"`
+    SMTPAddr     string `yaml:"smtp_addr"`
+    SMTPUsername string `yaml:"smtp_username"`
+    SMTPPassword string `yaml:"smtp_password"`
+    // VerifyURL is the page that receives the token from a verification
+    // email as its token query parameter.
+    VerifyURL string `yaml:"verify_url"`
+    // ResetURL is the same for password reset emails.
+    ResetURL string `yaml:"reset_url"`
+}
+
+// Default returns the configuration used when nothing overrides it.
+func Default() Config {
+    return Config{
+        Auth: Auth{
+            Issuer:            "chirpy",
+            AccessTokenTTL:    time.Hour,
+            RefreshTokenTTL:   60 * 24 * time.Hour,
+            ScopedTokenMaxTTL: 30 * 24 * time.Hour,
+            MaxFailedLogins:   10,
+            LockoutDuration:   15 * time.Minute,
+            EmailVerificationTTL: 48 * time.Hour,
+            PasswordResetTTL:     time.Hour,
+            PasswordMinLength:    8,
+            PasswordMaxLength:    256,
+            Argon2: Argon2{
+                MemoryKiB:   64 * 1024,
+                Iterations:  1,
+                Parallelism: 2,
+                SaltLength:  16,
+                KeyLength:   32,
+            },
+        },
+        Mail: Mail{
+            Transport: "log",
+            From:      "no-reply@localhost",
+            VerifyURL: "http://localhost:8080/verify-email",
+            ResetURL:  "http://localhost:8080/reset-password",
+        },
+    }
+}
+
+// Load builds the configuration from the optional YAML file named by
+// CONFIG_FILE and the environment, then validates it. getenv is usually
+// os.Getenv.
+func Load(getenv func(string) string) (Config, error) {
+    cfg := Default()
+
+    if path := getenv("CONFIG_FILE"); path != "" {
+        if err := cfg.loadFile(path); err != nil {
+            return cfg, err
+        }
+    }
+
+    if err := cfg.loadEnv(getenv); err != nil {
+        return cfg, err
+    }
+
+    return cfg, cfg.Validate()
+}
+
+func (c *Config) loadFile(path string) error {
+    f, err := os.Open(path)
+    if err != nil {
+        return fmt.Errorf("config file: %w", err)
+    }
+    defer f.Close()
+
+    dec := yaml.NewDecoder(f)
+    dec.KnownFields(true)
+    if err := dec.Decode(c); err != nil {
+        return fmt.Errorf("config file %s: %w", path, err)
+    }
+    return nil
+}
+
+func (c *Config) loadEnv(getenv func(string) string) error {
+    var errs []error
+
+    str := func(name string, dst *string) {
+        if v := getenv(name); v != "" {
+            *dst = v
+        }
+    }
+    duration := func(name string, dst *time.Duration) {
+        if v := getenv(name); v != "" {
+            d, err := time.ParseDuration(v)
+            if err != nil {
+                errs = append(errs, fmt.Errorf("%s: %q is not a duration like 1h or 30m", name, v))
+                return
+            }
+            *dst = d
+        }
+    }
+    intVar := func(name string, dst *int) {
+        if v := getenv(name); v != "" {
+            n, err := strconv.Atoi(v)
+            if err != nil {
+                errs = append(errs, fmt.Errorf("%s: %q is not an integer", name, v))
+                return
+            }
+            *dst = n
+        }
+    }
+    uint32Var := func(name string, dst *uint32) {
+        if v := getenv(name); v != "" {
+            n, err := strconv.ParseUint(v, 10, 32)
+            if err != nil {
+                errs = append(errs, fmt.Errorf("%s: %q is not a non-negative integer", name, v))
+                return
+            }
+            *dst = uint32(n)
+        }
+    }
+
+    str("PLATFORM", &c.Platform)
+    str("DB_URL", &c.DatabaseURL)
+    str("POLKA_KEY", &c.PolkaKey)
+    str("MODERATION_WORDS_FILE", &c.ModerationWordsFile)
+    if v := getenv("ALLOWED_ORIGINS"); v != "" {
+        c.AllowedOrigins = nil
+        for _, origin := range strings.Split(v, ",") {
+            if origin = strings.TrimSpace(origin); origin != "" {
+                c.AllowedOrigins = append(c.AllowedOrigins, origin)
+            }
+        }
+    }
+
+    str("JWT_SECRET", &c.Auth.JWTSecret)
+    str("JWT_KEYS_DIR", &c.Auth.JWTKeysDir)
+    str("JWT_ISSUER", &c.Auth.Issuer)
+    duration("ACCESS_TOKEN_TTL", &c.Auth.AccessTokenTTL)
+    duration("REFRESH_TOKEN_TTL", &c.Auth.RefreshTokenTTL)
+    duration("SCOPED_TOKEN_MAX_TTL", &c.Auth.ScopedTokenMaxTTL)
+    duration("LOCKOUT_DURATION", &c.Auth.LockoutDuration)
+    duration("EMAIL_VERIFICATION_TTL", &c.Auth.EmailVerificationTTL)
+    duration("PASSWORD_RESET_TTL", &c.Auth.PasswordResetTTL)
+    intVar("MAX_FAILED_LOGINS", &c.Auth.MaxFailedLogins)
+    intVar("PASSWORD_MIN_LENGTH", &c.Auth.PasswordMinLength)
+    intVar("PASSWORD_MAX_LENGTH", &c.Auth.PasswordMaxLength)
+    str("BREACHED_PASSWORDS_FILE", &c.Auth.BreachedPasswordsFile)
+    str("TOTP_ENCRYPTION_KEY", &c.Auth.TOTPEncryptionKey)
+
+    uint32Var("ARGON2_MEMORY_KIB", &c.Auth.Argon2.MemoryKiB)
+    uint32Var("ARGON2_ITERATIONS", &c.Auth.Argon2.Iterations)
+    uint32Var("ARGON2_SALT_LENGTH", &c.Auth.Argon2.SaltLength)
+    uint32Var("ARGON2_KEY_LENGTH", &c.Auth.Argon2.KeyLength)
+    if v := getenv("ARGON2_PARALLELISM"); v != "" {
+        n, err := strconv.ParseUint(v, 10, 8)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("ARGON2_PARALLELISM: %q is not an integer between 0 and 255", v))
+        } else {
+            c.Auth.Argon2.Parallelism = uint8(n)
+        }
+    }
+
+    str("MAIL_TRANSPORT", &c.Mail.Transport)
+    str("MAIL_DIR", &c.Mail.Dir)
+    str("MAIL_FROM", &c.Mail.From)
+    str("SMTP_ADDR", &c.Mail.SMTPAddr)
+    str("SMTP_USERNAME", &c.Mail.SMTPUsername)
+    str("SMTP_PASSWORD", &c.Mail.SMTPPassword)
+    str("EMAIL_VERIFY_URL", &c.Mail.VerifyURL)
+    str("PASSWORD_RESET_URL", &c.Mail.ResetURL)
+
+    return errors.Join(errs...)
+}
+
+// Validate reports every invalid setting at once, naming each by its
+// environment variable.
+func (c Config) Validate() error {
+    var errs []error
+    check := func(ok bool, format string, args ...any) {
+        if !ok {
+            errs = append(errs, fmt.Errorf(format, args...))
+        }
+    }
+
+    check(c.DatabaseURL != "", "DB_URL is required")
+    check(c.Auth.JWTSecret != "" || c.Auth.JWTKeysDir != "", "one of JWT_SECRET or JWT_KEYS_DIR is required")
+    check(c.Auth.Issuer != "", "JWT_ISSUER must not be empty")
+
+    check(c.Auth.AccessTokenTTL > 0, "ACCESS_TOKEN_TTL must be positive, got %s", c.Auth.AccessTokenTTL)
+    check(c.Auth.RefreshTokenTTL > c.Auth.AccessTokenTTL,
+        "REFRESH_TOKEN_TTL (%s) must be longer than ACCESS_TOKEN_TTL (%s)", c.Auth.RefreshTokenTTL, c.Auth.AccessTokenTTL)
+    check(c.Auth.ScopedTokenMaxTTL > 0, "SCOPED_TOKEN_MAX_TTL must be positive, got %s", c.Auth.ScopedTokenMaxTTL)
+    check(c.Auth.MaxFailedLogins >= 3, "MAX_FAILED_LOGINS must be at least 3, got %d", c.Auth.MaxFailedLogins)
+    check(c.Auth.LockoutDuration > 0, "LOCKOUT_DURATION must be positive, got %s", c.Auth.LockoutDuration)
+    check(c.Auth.EmailVerificationTTL > 0, "EMAIL_VERIFICATION_TTL must be positive, got %s", c.Auth.EmailVerificationTTL)
+    check(c.Auth.PasswordMinLength >= 1, "PASSWORD_MIN_LENGTH must be at least 1, got %d", c.Auth.PasswordMinLength)
+    check(c.Auth.PasswordMaxLength >= c.Auth.PasswordMinLength && c.Auth.PasswordMaxLength <= 4096,
+        "PASSWORD_MAX_LENGTH must be between PASSWORD_MIN_LENGTH and 4096, got %d", c.Auth.PasswordMaxLength)
+    check(c.Auth.PasswordResetTTL > 0, "PASSWORD_RESET_TTL must be positive, got %s", c.Auth.PasswordResetTTL)
+    if c.Auth.TOTPEncryptionKey != "" {
+        _, err := c.Auth.TOTPKey()
+        check(err == nil, "TOTP_ENCRYPTION_KEY %v", err)
+    }
+
+    a := c.Auth.Argon2
+    check(a.Iterations >= 1, "ARGON2_ITERATIONS must be at least 1")
+    check(a.Parallelism >= 1, "ARGON2_PARALLELISM must be at least 1")
+    check(a.MemoryKiB >= 8*uint32(a.Parallelism),
+        "ARGON2_MEMORY_KIB must be at least 8 times ARGON2_PARALLELISM, got %d", a.MemoryKiB)
+    check(a.SaltLength >= 8, "ARGON2_SALT_LENGTH must be at least 8 bytes, got %d", a.SaltLength)
+    check(a.KeyLength >= 16, "ARGON2_KEY_LENGTH must be at least 16 bytes, got %d", a.KeyLength)
+
+    switch c.Mail.Transport {
+    case "log":
+    case "file":
+        check(c.Mail.Dir != "", "MAIL_DIR is required when MAIL_TRANSPORT is file")
+    case "smtp":
+        check(c.Mail.SMTPAddr != "", "SMTP_ADDR is required when MAIL_TRANSPORT is smtp")
+    default:
+        check(false, "MAIL_TRANSPORT must be log, file or smtp, got %q", c.Mail.Transport)
+    }
+    _, err := mail.ParseAddress(c.Mail.From)
+    check(err == nil, "MAIL_FROM: %q is not an email address", c.Mail.From)
+    check(validURL(c.Mail.VerifyURL), "EMAIL_VERIFY_URL: %q must be an http or https URL", c.Mail.VerifyURL)
+    check(validURL(c.Mail.ResetURL), "PASSWORD_RESET_URL: %q must be an http or https URL", c.Mail.ResetURL)
+
+    for _, origin := range c.AllowedOrigins {
+        check(validOrigin(origin), "ALLOWED_ORIGINS: %q must be * or a scheme and host like https://example.com", origin)
+    }
+
+    return errors.Join(errs...)
+}
+
+func validURL(s string) bool {
+    u, err := url.Parse(s)
+    return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validOrigin(origin string) bool {
+    if origin == "*" {
+        return true
+    }
+    u, err := url.Parse(origin)
+    if err != nil {
+        return false
+    }
+    return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+        u.Path == "" && u.RawQuery == "" && u.User == nil
+}
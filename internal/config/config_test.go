@@ -0,0 +1,108 @@
+package config
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func envMap(vars map[string]string) func(string) string {
+    return func(name string) string { return vars[name] }
+}
+
+func TestLoad_Defaults(t *testing.T) {
+    cfg, err := Load(envMap(map[string]string{
+        "DB_URL":     "postgres://localhost/chirpy",
+        "JWT_SECRET": "secret",
+    }))
+    require.NoError(t, err)
+    assert.Equal(t, time.Hour, cfg.Auth.AccessTokenTTL)
+    assert.Equal(t, 60*24*time.Hour, cfg.Auth.RefreshTokenTTL)
+    assert.Equal(t, "chirpy", cfg.Auth.Issuer)
+    assert.Equal(t, Default().Auth.Argon2, cfg.Auth.Argon2)
+    assert.Equal(t, "log", cfg.Mail.Transport)
+}
+
+func TestLoad_FileThenEnv(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "chirpy.yaml")
+    require.NoError(t, os.WriteFile(path, []byte(`
+database_url: postgres://file/chirpy
+allowed_origins:
+  - https://chirpy.example
+auth:
+  jwt_keys_dir: /etc/chirpy/keys
+  access_token_ttl: 15m
+  refresh_token_ttl: 720h
+  argon2:
+    memory_kib: 131072
+    iterations: 3
+`), 0o600))
+
+    cfg, err := Load(envMap(map[string]string{
+        "CONFIG_FILE":      path,
+        "ACCESS_TOKEN_TTL": "5m",
+        "ALLOWED_ORIGINS":  "https://a.example, https://b.example",
+    }))
+    require.NoError(t, err)
+
+    assert.Equal(t, "postgres://file/chirpy", cfg.DatabaseURL)
+    assert.Equal(t, "/etc/chirpy/keys", cfg.Auth.JWTKeysDir)
+    assert.Equal(t, 5*time.Minute, cfg.Auth.AccessTokenTTL, "env overrides the file")
+    assert.Equal(t, 720*time.Hour, cfg.Auth.RefreshTokenTTL)
+    assert.Equal(t, uint32(131072), cfg.Auth.Argon2.MemoryKiB)
+    assert.Equal(t, uint32(3), cfg.Auth.Argon2.Iterations)
+    assert.Equal(t, uint8(2), cfg.Auth.Argon2.Parallelism, "unset fields keep their defaults")
+    assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.AllowedOrigins)
+}
+
+func TestLoad_UnknownFileField(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "chirpy.yaml")
+    require.NoError(t, os.WriteFile(path, []byte("auth:\n  acess_token_ttl: 1h\n"), 0o600))
+
+    _, err := Load(envMap(map[string]string{"CONFIG_FILE": path}))
+    assert.ErrorContains(t, err, "acess_token_ttl")
+}
+
+func TestLoad_Invalid(t *testing.T) {
+    tests := []struct {
+        name    string
+        env     map[string]string
+        wantErr string
+    }{
+        {"missing database", map[string]string{"JWT_SECRET": "s"}, "DB_URL is required"},
+        {"missing signing key", map[string]string{"DB_URL": "db"}, "one of JWT_SECRET or JWT_KEYS_DIR is required"},
+        {"bad duration", map[string]string{"DB_URL": "db", "JWT_SECRET": "s", "ACCESS_TOKEN_TTL": "an hour"}, `ACCESS_TOKEN_TTL: "an hour" is not a duration`},
+        {"refresh shorter than access", map[string]string{"DB_URL": "db", "JWT_SECRET": "s", "REFRESH_TOKEN_TTL": "30m"}, "REFRESH_TOKEN_TTL (30m0s) must be longer than ACCESS_TOKEN_TTL (1h0m0s)"},
+        {"argon2 memory", map[string]string{"DB_URL": "db", "JWT_SECRET": "s", "ARGON2_MEMORY_KIB": "8"}, "ARGON2_MEMORY_KIB must be at least 8 times ARGON2_PARALLELISM"},
+        {"argon2 parallelism", map[string]string{"DB_URL": "db", "JWT_SECRET": "s", "ARGON2_PARALLELISM": "300"}, "ARGON2_PARALLELISM"},
+        {"lockout threshold", map[string]string{"DB_URL": "db", "JWT_SECRET": "s", "MAX_FAILED_LOGINS": "1"}, "MAX_FAILED_LOGINS must be at least 3, got 1"},
+        {"mail transport", map[string]string{"DB_URL": "db", "JWT_SECRET": "s", "MAIL_TRANSPORT": "pigeon"}, `MAIL_TRANSPORT must be log, file or smtp, got "pigeon"`},
+        {"smtp without address", map[string]string{"DB_URL": "db", "JWT_SECRET": "s", "MAIL_TRANSPORT": "smtp"}, "SMTP_ADDR is required"},
+        {"reset url", map[string]string{"DB_URL": "db", "JWT_SECRET": "s", "PASSWORD_RESET_URL": "/reset"}, `PASSWORD_RESET_URL: "/reset" must be an http or https URL`},
+        {"password lengths", map[string]string{"DB_URL": "db", "JWT_SECRET": "s", "PASSWORD_MIN_LENGTH": "20", "PASSWORD_MAX_LENGTH": "10"}, "PASSWORD_MAX_LENGTH must be between PASSWORD_MIN_LENGTH and 4096, got 10"},
+        {"short totp key", map[string]string{"DB_URL": "db", "JWT_SECRET": "s", "TOTP_ENCRYPTION_KEY": "c2hvcnQ="}, "TOTP_ENCRYPTION_KEY must be 32 bytes"},
+        {"origin with path", map[string]string{"DB_URL": "db", "JWT_SECRET": "s", "ALLOWED_ORIGINS": "https://a.example/app"}, "ALLOWED_ORIGINS"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := Load(envMap(tt.env))
+            assert.ErrorContains(t, err, tt.wantErr)
+        })
+    }
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+    cfg := Default()
+    cfg.Auth.AccessTokenTTL = 0
+
+    err := cfg.Validate()
+    require.Error(t, err)
+    assert.ErrorContains(t, err, "DB_URL is required")
+    assert.ErrorContains(t, err, "one of JWT_SECRET or JWT_KEYS_DIR is required")
+    assert.ErrorContains(t, err, "ACCESS_TOKEN_TTL must be positive")
+}
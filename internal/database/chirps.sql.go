@@ -7,23 +7,27 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
-RETURNING id, created_at, updated_at, body, user_id
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, reply_to_id)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3)
+RETURNING id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body      string
+	UserID    uuid.UUID
+	ReplyToID uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.ReplyToID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -31,6 +35,66 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.ReplyToID,
+		&i.RepostOfID,
+		&i.IsRepost,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const createChirpRevision = `-- name: CreateChirpRevision :one
+INSERT INTO chirp_revisions (id, chirp_id, body, created_at, replaced_at)
+VALUES (gen_random_uuid(), $1, $2, $3, NOW())
+RETURNING id, chirp_id, body, created_at, replaced_at
+`
+
+type CreateChirpRevisionParams struct {
+	ChirpID   uuid.UUID
+	Body      string
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateChirpRevision(ctx context.Context, arg CreateChirpRevisionParams) (ChirpRevision, error) {
+	row := q.db.QueryRowContext(ctx, createChirpRevision, arg.ChirpID, arg.Body, arg.CreatedAt)
+	var i ChirpRevision
+	err := row.Scan(
+		&i.ID,
+		&i.ChirpID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.ReplacedAt,
+	)
+	return i, err
+}
+
+const createRepost = `-- name: CreateRepost :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, repost_of_id, is_repost)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, true)
+RETURNING id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at
+`
+
+type CreateRepostParams struct {
+	Body       string
+	UserID     uuid.UUID
+	RepostOfID uuid.NullUUID
+}
+
+func (q *Queries) CreateRepost(ctx context.Context, arg CreateRepostParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRepost, arg.Body, arg.UserID, arg.RepostOfID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ReplyToID,
+		&i.RepostOfID,
+		&i.IsRepost,
+		&i.HiddenAt,
 	)
 	return i, err
 }
@@ -55,7 +119,7 @@ func (q *Queries) DeleteChirps(ctx context.Context) error {
 }
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at FROM chirps
 WHERE id = $1
 `
 
@@ -68,12 +132,17 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.ReplyToID,
+		&i.RepostOfID,
+		&i.IsRepost,
+		&i.HiddenAt,
 	)
 	return i, err
 }
 
 const getChirpByUserId = `-- name: GetChirpByUserId :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at FROM chirps
 WHERE user_id = $1
 `
 
@@ -92,6 +161,185 @@ func (q *Queries) GetChirpByUserId(ctx context.Context, userID uuid.UUID) ([]Chi
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.IsRepost,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at FROM chirps
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ReplyToID,
+		&i.RepostOfID,
+		&i.IsRepost,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const getChirpReplies = `-- name: GetChirpReplies :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at FROM chirps
+WHERE reply_to_id = $1
+  AND hidden_at IS NULL
+  AND ($2::timestamptz IS NULL
+       OR (created_at, id) > ($2, $3::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type GetChirpRepliesParams struct {
+	ChirpID         uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) GetChirpReplies(ctx context.Context, arg GetChirpRepliesParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpReplies,
+		arg.ChirpID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.IsRepost,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpRevisions = `-- name: GetChirpRevisions :many
+SELECT id, chirp_id, body, created_at, replaced_at FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY replaced_at
+`
+
+func (q *Queries) GetChirpRevisions(ctx context.Context, chirpID uuid.UUID) ([]ChirpRevision, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpRevisions, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpRevision
+	for rows.Next() {
+		var i ChirpRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ReplacedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpThread = `-- name: GetChirpThread :many
+WITH RECURSIVE ancestors AS (
+    SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.reply_to_id, chirps.repost_of_id, chirps.is_repost, chirps.hidden_at, 0 AS depth FROM chirps
+    WHERE chirps.id = $1
+    UNION ALL
+    SELECT parent.id, parent.created_at, parent.updated_at, parent.body, parent.user_id, parent.search_vector, parent.reply_to_id, parent.repost_of_id, parent.is_repost, parent.hidden_at, ancestors.depth + 1 FROM chirps parent
+    JOIN ancestors ON parent.id = ancestors.reply_to_id
+)
+SELECT id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at FROM ancestors
+ORDER BY depth DESC
+`
+
+type GetChirpThreadRow struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Body         string
+	UserID       uuid.UUID
+	SearchVector interface{}
+	ReplyToID    uuid.NullUUID
+	RepostOfID   uuid.NullUUID
+	IsRepost     bool
+	HiddenAt     sql.NullTime
+}
+
+func (q *Queries) GetChirpThread(ctx context.Context, id uuid.UUID) ([]GetChirpThreadRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpThread, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpThreadRow
+	for rows.Next() {
+		var i GetChirpThreadRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.IsRepost,
+			&i.HiddenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -107,7 +355,7 @@ func (q *Queries) GetChirpByUserId(ctx context.Context, userID uuid.UUID) ([]Chi
 }
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at FROM chirps
 ORDER BY created_at
 `
 
@@ -126,6 +374,11 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.IsRepost,
+			&i.HiddenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -139,3 +392,273 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	}
 	return items, nil
 }
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at FROM chirps
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.IsRepost,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hideChirp = `-- name: HideChirp :exec
+UPDATE chirps
+SET hidden_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) HideChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, hideChirp, id)
+	return err
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at FROM chirps
+WHERE ($1::uuid IS NULL OR user_id = $1)
+  AND ($2::timestamptz IS NULL
+       OR (created_at, id) > ($2, $3::uuid))
+  AND (hidden_at IS NULL OR user_id = $4)
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type ListChirpsAscParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.IsRepost,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at FROM chirps
+WHERE ($1::uuid IS NULL OR user_id = $1)
+  AND ($2::timestamptz IS NULL
+       OR (created_at, id) < ($2, $3::uuid))
+  AND (hidden_at IS NULL OR user_id = $4)
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type ListChirpsDescParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.IsRepost,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT id, created_at, updated_at, body, user_id, reply_to_id, repost_of_id, is_repost,
+       ts_rank(search_vector, to_tsquery('english', $1))::real AS rank,
+       ts_headline('english', body, to_tsquery('english', $1),
+                   $2::text)::text AS snippet
+FROM chirps
+WHERE search_vector @@ to_tsquery('english', $1)
+  AND hidden_at IS NULL
+  AND ($3::uuid IS NULL OR user_id = $3)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT $4 OFFSET $5
+`
+
+type SearchChirpsParams struct {
+	Query           string
+	HeadlineOptions string
+	AuthorID        uuid.NullUUID
+	PageSize        int32
+	PageOffset      int32
+}
+
+type SearchChirpsRow struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Body       string
+	UserID     uuid.UUID
+	ReplyToID  uuid.NullUUID
+	RepostOfID uuid.NullUUID
+	IsRepost   bool
+	Rank       float32
+	Snippet    string
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.HeadlineOptions,
+		arg.AuthorID,
+		arg.PageSize,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.IsRepost,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirp = `-- name: UpdateChirp :one
+UPDATE chirps
+SET body = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at
+`
+
+type UpdateChirpParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+func (q *Queries) UpdateChirp(ctx context.Context, arg UpdateChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirp, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ReplyToID,
+		&i.RepostOfID,
+		&i.IsRepost,
+		&i.HiddenAt,
+	)
+	return i, err
+}
@@ -11,28 +11,148 @@ import (
 	"github.com/google/uuid"
 )
 
+type BannedTerm struct {
+	Term      string
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Body         string
+	UserID       uuid.UUID
+	SearchVector interface{}
+	ReplyToID    uuid.NullUUID
+	RepostOfID   uuid.NullUUID
+	IsRepost     bool
+	HiddenAt     sql.NullTime
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type DeniedSession struct {
+	SessionID uuid.UUID
+	ExpiresAt time.Time
+}
+
+type DeniedToken struct {
+	Jti       string
+	ExpiresAt time.Time
+}
+
+type EmailVerificationToken struct {
+	Jti       string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
 	ID        uuid.UUID
+	Tag       string
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	Body      string
+}
+
+type Like struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Mention struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type PasswordResetToken struct {
+	TokenHash string
 	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type RecoveryCode struct {
+	UserID    uuid.UUID
+	CodeHash  string
+	CreatedAt time.Time
+	UsedAt    sql.NullTime
 }
 
 type RefreshToken struct {
-	Token     string
+	TokenHash string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	ExpiresAt time.Time
 	RevokedAt sql.NullTime
+	FamilyID  uuid.UUID
+}
+
+type Report struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	ChirpID    uuid.NullUUID
+	ReporterID uuid.UUID
+	Reason     string
+	Details    string
+	Status     string
+	ResolvedAt sql.NullTime
+	ResolvedBy uuid.NullUUID
+}
+
+type Session struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	UserAgent  string
+	IpAddress  string
+}
+
+type TotpCredential struct {
+	UserID       uuid.UUID
+	Secret       string
+	CreatedAt    time.Time
+	ConfirmedAt  sql.NullTime
+	LastUsedStep int64
 }
 
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Email             string
+	HashedPassword    string
+	IsChirpyRed       bool
+	Role              string
+	BannedAt          sql.NullTime
+	FailedLoginCount  int32
+	LastFailedLoginAt sql.NullTime
+	EmailVerifiedAt   sql.NullTime
+}
+
+type UserTokenRevocation struct {
+	UserID        uuid.UUID
+	RevokedBefore time.Time
+	ExpiresAt     time.Time
 }
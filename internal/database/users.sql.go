@@ -13,34 +13,78 @@ import (
 	"github.com/google/uuid"
 )
 
+const banUser = `-- name: BanUser :one
+UPDATE users
+SET banned_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, banned_at, failed_login_count, last_failed_login_at, email_verified_at
+`
+
+func (q *Queries) BanUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, banUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.BannedAt,
+		&i.FailedLoginCount,
+		&i.LastFailedLoginAt,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const consumeRefreshToken = `-- name: ConsumeRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE token_hash = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) ConsumeRefreshToken(ctx context.Context, tokenHash string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, consumeRefreshToken, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
-VALUES ($1, NOW(), NOW(), $2, $3, $4)
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+INSERT INTO refresh_tokens (token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id)
+VALUES ($1, NOW(), NOW(), $2, $3, $4, $5)
+RETURNING token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id
 `
 
 type CreateRefreshTokenParams struct {
-	Token     string
+	TokenHash string
 	UserID    uuid.UUID
 	ExpiresAt time.Time
 	RevokedAt sql.NullTime
+	FamilyID  uuid.UUID
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
 	row := q.db.QueryRowContext(ctx, createRefreshToken,
-		arg.Token,
+		arg.TokenHash,
 		arg.UserID,
 		arg.ExpiresAt,
 		arg.RevokedAt,
+		arg.FamilyID,
 	)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
 	)
 	return i, err
 }
@@ -48,7 +92,7 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, email, hashed_password)
 VALUES (gen_random_uuid(), NOW(), NOW(),  $1, $2)
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, banned_at, failed_login_count, last_failed_login_at, email_verified_at
 `
 
 type CreateUserParams struct {
@@ -66,6 +110,11 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.BannedAt,
+		&i.FailedLoginCount,
+		&i.LastFailedLoginAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -80,7 +129,7 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 }
 
 const findUser = `-- name: FindUser :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, banned_at, failed_login_count, last_failed_login_at FROM users
 WHERE email = $1
 `
 
@@ -94,56 +143,186 @@ func (q *Queries) FindUser(ctx context.Context, email string) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.BannedAt,
+		&i.FailedLoginCount,
+		&i.LastFailedLoginAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at FROM refresh_tokens
-WHERE token = $1
+SELECT token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id FROM refresh_tokens
+WHERE token_hash = $1
 `
 
-func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshToken, token)
+func (q *Queries) GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshToken, tokenHash)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, banned_at, failed_login_count, last_failed_login_at FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.BannedAt,
+		&i.FailedLoginCount,
+		&i.LastFailedLoginAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
-const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
+const recordFailedLogin = `-- name: RecordFailedLogin :one
+UPDATE users
+SET failed_login_count = CASE
+        WHEN last_failed_login_at IS NULL OR last_failed_login_at < $1::timestamptz THEN 1
+        ELSE failed_login_count + 1
+    END,
+    last_failed_login_at = NOW()
+WHERE id = $2
+RETURNING failed_login_count
+`
+
+type RecordFailedLoginParams struct {
+	WindowStart time.Time
+	ID          uuid.UUID
+}
+
+func (q *Queries) RecordFailedLogin(ctx context.Context, arg RecordFailedLoginParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, recordFailedLogin, arg.WindowStart, arg.ID)
+	var failed_login_count int32
+	err := row.Scan(&failed_login_count)
+	return failed_login_count, err
+}
+
+const resetFailedLogins = `-- name: ResetFailedLogins :execrows
+UPDATE users
+SET failed_login_count = 0, last_failed_login_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) ResetFailedLogins(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resetFailedLogins, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeRefreshToken = `-- name: RevokeRefreshToken :execrows
 UPDATE refresh_tokens 
 SET revoked_at = NOW(), 
     updated_at = NOW()
-WHERE token = $1
+WHERE token_hash = $1
 `
 
-func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
-	return err
+func (q *Queries) RevokeRefreshToken(ctx context.Context, tokenHash string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRefreshToken, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const updateRefreshToken = `-- name: UpdateRefreshToken :exec
-UPDATE refresh_tokens SET updated_at = NOW() 
-WHERE token = $1
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL
 `
 
-func (q *Queries) UpdateRefreshToken(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, updateRefreshToken, token)
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
 	return err
 }
 
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, banned_at, failed_login_count, last_failed_login_at, email_verified_at
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.BannedAt,
+		&i.FailedLoginCount,
+		&i.LastFailedLoginAt,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const unbanUser = `-- name: UnbanUser :one
+UPDATE users
+SET banned_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, banned_at, failed_login_count, last_failed_login_at, email_verified_at
+`
+
+func (q *Queries) UnbanUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, unbanUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.BannedAt,
+		&i.FailedLoginCount,
+		&i.LastFailedLoginAt,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
-SET email = $2, hashed_password = $3, updated_at = NOW()
+SET email = $2, hashed_password = $3, updated_at = NOW(),
+    email_verified_at = CASE WHEN email = $2 THEN email_verified_at END
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, banned_at, failed_login_count, last_failed_login_at, email_verified_at
 `
 
 type UpdateUserParams struct {
@@ -162,15 +341,36 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.BannedAt,
+		&i.FailedLoginCount,
+		&i.LastFailedLoginAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET hashed_password = $2
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID             uuid.UUID
+	HashedPassword string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.HashedPassword)
+	return err
+}
+
 const upgradeUserToRed = `-- name: UpgradeUserToRed :one
 UPDATE users
 SET is_chirpy_red = true, updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, banned_at, failed_login_count, last_failed_login_at, email_verified_at
 `
 
 func (q *Queries) UpgradeUserToRed(ctx context.Context, id uuid.UUID) (User, error) {
@@ -183,6 +383,11 @@ func (q *Queries) UpgradeUserToRed(ctx context.Context, id uuid.UUID) (User, err
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.BannedAt,
+		&i.FailedLoginCount,
+		&i.LastFailedLoginAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
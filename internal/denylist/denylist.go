@@ -0,0 +1,163 @@
+package denylist
+
+import (
+    "context"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// UserRevocation revokes every token issued to a user up to RevokedBefore.
+// It can be forgotten at ExpiresAt, once all such tokens have expired.
+type UserRevocation struct {
+    UserID        uuid.UUID
+    RevokedBefore time.Time
+    ExpiresAt     time.Time
+}
+
+// Store persists denylist entries so they are shared between instances and
+// survive restarts.
+type Store interface {
+    // ListTokens returns the unexpired revoked token IDs and their expiry.
+    ListTokens(ctx context.Context) (map[string]time.Time, error)
+    ListUsers(ctx context.Context) ([]UserRevocation, error)
+    // ListSessions returns the unexpired revoked session IDs and their expiry.
+    ListSessions(ctx context.Context) (map[uuid.UUID]time.Time, error)
+    AddToken(ctx context.Context, jti string, expiresAt time.Time) error
+    AddUser(ctx context.Context, rev UserRevocation) error
+    AddSession(ctx context.Context, sessionID uuid.UUID, expiresAt time.Time) error
+    DeleteExpired(ctx context.Context) error
+}
+
+// Denylist answers whether an access token has been revoked before it
+// expired. Lookups are served from memory; Reload picks up entries written
+// by other instances.
+type Denylist struct {
+    store Store
+    now   func() time.Time
+
+    mu     sync.RWMutex
+    tokens   map[string]time.Time
+    users    map[uuid.UUID]UserRevocation
+    sessions map[uuid.UUID]time.Time
+}
+
+// New creates a denylist and loads its entries from store.
+func New(ctx context.Context, store Store) (*Denylist, error) {
+    d := &Denylist{store: store, now: time.Now}
+    if err := d.Reload(ctx); err != nil {
+        return nil, err
+    }
+    return d, nil
+}
+
+// Reload drops expired entries from the store and replaces the in-memory
+// entries with the remaining ones.
+func (d *Denylist) Reload(ctx context.Context) error {
+    if err := d.store.DeleteExpired(ctx); err != nil {
+        return err
+    }
+
+    tokens, err := d.store.ListTokens(ctx)
+    if err != nil {
+        return err
+    }
+
+    revs, err := d.store.ListUsers(ctx)
+    if err != nil {
+        return err
+    }
+    sessions, err := d.store.ListSessions(ctx)
+    if err != nil {
+        return err
+    }
+
+    users := make(map[uuid.UUID]UserRevocation, len(revs))
+    for _, rev := range revs {
+        users[rev.UserID] = rev
+    }
+
+    d.mu.Lock()
+    d.tokens = tokens
+    d.users = users
+    d.sessions = sessions
+    d.mu.Unlock()
+    return nil
+}
+
+// RevokeToken denies the token with ID jti until it expires.
+func (d *Denylist) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+    if err := d.store.AddToken(ctx, jti, expiresAt); err != nil {
+        return err
+    }
+
+    d.mu.Lock()
+    d.tokens[jti] = expiresAt
+    d.mu.Unlock()
+    return nil
+}
+
+// RevokeUser denies every token issued to userID so far that belongs to no
+// session; tokens with a session are revoked through RevokeSession instead.
+// maxTTL is the longest lifetime any access token can have; after that the
+// entry is dropped.
+func (d *Denylist) RevokeUser(ctx context.Context, userID uuid.UUID, maxTTL time.Duration) error {
+    now := d.now()
+    rev := UserRevocation{
+        UserID:        userID,
+        RevokedBefore: now,
+        ExpiresAt:     now.Add(maxTTL),
+    }
+    if err := d.store.AddUser(ctx, rev); err != nil {
+        return err
+    }
+
+    d.mu.Lock()
+    d.users[userID] = rev
+    d.mu.Unlock()
+    return nil
+}
+
+// RevokeSession denies every token issued to the login session sessionID.
+// A revoked session never gets new tokens, so unlike RevokeUser no cut-off
+// time is needed; the entry is dropped after maxTTL.
+func (d *Denylist) RevokeSession(ctx context.Context, sessionID uuid.UUID, maxTTL time.Duration) error {
+    expiresAt := d.now().Add(maxTTL)
+    if err := d.store.AddSession(ctx, sessionID, expiresAt); err != nil {
+        return err
+    }
+
+    d.mu.Lock()
+    d.sessions[sessionID] = expiresAt
+    d.mu.Unlock()
+    return nil
+}
+
+// IsRevoked reports whether the token with ID jti, issued to userID at
+// issuedAt, has been revoked. sessionID is the session the token was issued
+// to, or uuid.Nil if it has none.
+func (d *Denylist) IsRevoked(jti string, userID, sessionID uuid.UUID, issuedAt time.Time) bool {
+    now := d.now()
+
+    d.mu.RLock()
+    defer d.mu.RUnlock()
+
+    if expiresAt, ok := d.tokens[jti]; ok && now.Before(expiresAt) {
+        return true
+    }
+
+    if expiresAt, ok := d.sessions[sessionID]; ok && now.Before(expiresAt) {
+        return true
+    }
+
+    // iat has one-second resolution, so a token issued in the same second as
+    // the revocation is treated as revoked too. Only tokens without a session
+    // are judged by time: a login right after the revocation would otherwise
+    // get a token that is already dead.
+    if rev, ok := d.users[userID]; ok && sessionID == uuid.Nil && now.Before(rev.ExpiresAt) {
+        return !issuedAt.After(rev.RevokedBefore.Truncate(time.Second))
+    }
+
+    return false
+}
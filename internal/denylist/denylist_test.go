@@ -0,0 +1,138 @@
+package denylist
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+    tokens   map[string]time.Time
+    users    []UserRevocation
+    sessions map[uuid.UUID]time.Time
+}
+
+func newMemoryStore() *memoryStore {
+    return &memoryStore{tokens: map[string]time.Time{}, sessions: map[uuid.UUID]time.Time{}}
+}
+
+func (s *memoryStore) ListTokens(_ context.Context) (map[string]time.Time, error) {
+    tokens := make(map[string]time.Time, len(s.tokens))
+    for jti, exp := range s.tokens {
+        tokens[jti] = exp
+    }
+    return tokens, nil
+}
+
+func (s *memoryStore) ListUsers(_ context.Context) ([]UserRevocation, error) { return s.users, nil }
+
+func (s *memoryStore) ListSessions(_ context.Context) (map[uuid.UUID]time.Time, error) {
+    sessions := make(map[uuid.UUID]time.Time, len(s.sessions))
+    for id, exp := range s.sessions {
+        sessions[id] = exp
+    }
+    return sessions, nil
+}
+
+func (s *memoryStore) AddToken(_ context.Context, jti string, expiresAt time.Time) error {
+    s.tokens[jti] = expiresAt
+    return nil
+}
+
+func (s *memoryStore) AddUser(_ context.Context, rev UserRevocation) error {
+    s.users = append(s.users, rev)
+    return nil
+}
+
+func (s *memoryStore) AddSession(_ context.Context, sessionID uuid.UUID, expiresAt time.Time) error {
+    s.sessions[sessionID] = expiresAt
+    return nil
+}
+
+func (s *memoryStore) DeleteExpired(_ context.Context) error { return nil }
+
+func TestRevokeToken(t *testing.T) {
+    now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+    d, err := New(context.Background(), newMemoryStore())
+    require.NoError(t, err)
+    d.now = func() time.Time { return now }
+
+    userID := uuid.New()
+    require.NoError(t, d.RevokeToken(context.Background(), "abc", now.Add(time.Hour)))
+
+    assert.True(t, d.IsRevoked("abc", userID, uuid.Nil, now.Add(-time.Minute)))
+    assert.False(t, d.IsRevoked("other", userID, uuid.Nil, now.Add(-time.Minute)))
+
+    // Once the token has expired the entry no longer matters.
+    now = now.Add(2 * time.Hour)
+    assert.False(t, d.IsRevoked("abc", userID, uuid.Nil, now.Add(-time.Minute)))
+}
+
+func TestRevokeUser(t *testing.T) {
+    now := time.Date(2026, 10, 1, 12, 0, 0, 500_000_000, time.UTC)
+    d, err := New(context.Background(), newMemoryStore())
+    require.NoError(t, err)
+    d.now = func() time.Time { return now }
+
+    userID := uuid.New()
+    require.NoError(t, d.RevokeUser(context.Background(), userID, time.Hour))
+
+    tests := []struct {
+        name      string
+        userID    uuid.UUID
+        sessionID uuid.UUID
+        issuedAt  time.Time
+        want      bool
+    }{
+        {"issued before", userID, uuid.Nil, now.Add(-time.Minute), true},
+        {"issued in the same second", userID, uuid.Nil, now.Truncate(time.Second), true},
+        {"issued after", userID, uuid.Nil, now.Add(time.Second).Truncate(time.Second), false},
+        {"other user", uuid.New(), uuid.Nil, now.Add(-time.Minute), false},
+        // A login straight after the revocation starts a new session.
+        {"new session in the same second", userID, uuid.New(), now.Truncate(time.Second), false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            assert.Equal(t, tt.want, d.IsRevoked("jti", tt.userID, tt.sessionID, tt.issuedAt))
+        })
+    }
+
+    now = now.Add(2 * time.Hour)
+    assert.False(t, d.IsRevoked("jti", userID, uuid.Nil, now.Add(-3*time.Hour)))
+}
+
+func TestRevokeSession(t *testing.T) {
+    now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+    d, err := New(context.Background(), newMemoryStore())
+    require.NoError(t, err)
+    d.now = func() time.Time { return now }
+
+    userID := uuid.New()
+    sessionID := uuid.New()
+    require.NoError(t, d.RevokeSession(context.Background(), sessionID, time.Hour))
+
+    assert.True(t, d.IsRevoked("jti", userID, sessionID, now.Add(-time.Minute)))
+    assert.False(t, d.IsRevoked("jti", userID, uuid.New(), now.Add(-time.Minute)), "other sessions are unaffected")
+    assert.False(t, d.IsRevoked("jti", userID, uuid.Nil, now.Add(-time.Minute)))
+
+    now = now.Add(2 * time.Hour)
+    assert.False(t, d.IsRevoked("jti", userID, sessionID, now.Add(-3*time.Hour)))
+}
+
+func TestReload(t *testing.T) {
+    store := newMemoryStore()
+    d, err := New(context.Background(), store)
+    require.NoError(t, err)
+
+    // Another instance revokes a token.
+    store.tokens["abc"] = time.Now().Add(time.Hour)
+    assert.False(t, d.IsRevoked("abc", uuid.New(), uuid.Nil, time.Now()))
+
+    require.NoError(t, d.Reload(context.Background()))
+    assert.True(t, d.IsRevoked("abc", uuid.New(), uuid.Nil, time.Now()))
+}
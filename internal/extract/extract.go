@@ -0,0 +1,77 @@
+package extract
+
+import (
+    "strings"
+    "unicode"
+)
+
+const maxTagLength = 100
+
+// Hashtags returns the distinct #tags in body, lowercased and without the
+// leading '#'. A tag must start a word, so "a#b" is not a tag.
+func Hashtags(body string) []string {
+    return scan(body, '#', isTagRune)
+}
+
+// Mentions returns the distinct @mentions in body, lowercased and without
+// the leading '@'. Accounts are addressed by email, e.g. "@walt@example.com".
+func Mentions(body string) []string {
+    mentions := scan(body, '@', isMentionRune)
+
+    result := mentions[:0]
+    for _, m := range mentions {
+        // Trailing dots are sentence punctuation, not part of the address.
+        m = strings.TrimRight(m, ".")
+        if strings.Count(m, "@") == 1 && !strings.HasPrefix(m, "@") && !strings.HasSuffix(m, "@") {
+            result = append(result, m)
+        }
+    }
+    return dedupe(result)
+}
+
+func scan(body string, marker rune, valid func(rune) bool) []string {
+    var found []string
+    runes := []rune(body)
+
+    for i := 0; i < len(runes); i++ {
+        if runes[i] != marker {
+            continue
+        }
+        if i > 0 && (isTagRune(runes[i-1]) || runes[i-1] == marker) {
+            continue
+        }
+
+        j := i + 1
+        for j < len(runes) && valid(runes[j]) {
+            j++
+        }
+
+        if j > i+1 && j-i-1 <= maxTagLength {
+            found = append(found, strings.ToLower(string(runes[i+1:j])))
+        }
+        i = j - 1
+    }
+
+    return dedupe(found)
+}
+
+func dedupe(items []string) []string {
+    seen := make(map[string]bool, len(items))
+    result := make([]string, 0, len(items))
+    for _, item := range items {
+        if item == "" || seen[item] {
+            continue
+        }
+        seen[item] = true
+        result = append(result, item)
+    }
+    return result
+}
+
+func isTagRune(r rune) bool {
+    return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isMentionRune(r rune) bool {
+    return isTagRune(r) || strings.ContainsRune(".+-@", r)
+}
@@ -0,0 +1,49 @@
+package extract
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestHashtags(t *testing.T) {
+    tests := []struct {
+        name string
+        body string
+        want []string
+    }{
+        {"none", "just a chirp", []string{}},
+        {"single", "hello #Golang", []string{"golang"}},
+        {"punctuation ends tag", "#go, #rust!", []string{"go", "rust"}},
+        {"deduplicated", "#Go #go #GO", []string{"go"}},
+        {"mid-word is not a tag", "issue#42", []string{}},
+        {"unicode", "#café time", []string{"café"}},
+        {"bare hash", "# #", []string{}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            assert.Equal(t, tt.want, Hashtags(tt.body))
+        })
+    }
+}
+
+func TestMentions(t *testing.T) {
+    tests := []struct {
+        name string
+        body string
+        want []string
+    }{
+        {"none", "no one here", []string{}},
+        {"email", "hi @Walt@Example.com.", []string{"walt@example.com"}},
+        {"two", "@a@x.io and @b@y.io", []string{"a@x.io", "b@y.io"}},
+        {"not an address", "@everyone", []string{}},
+        {"inside a word", "mail me at walt@example.com", []string{}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            assert.Equal(t, tt.want, Mentions(tt.body))
+        })
+    }
+}
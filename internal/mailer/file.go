@@ -0,0 +1,51 @@
+package mailer
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "log"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// FileMailer writes each message to its own .eml file in Dir instead of
+// sending it. It is meant for local development and tests.
+type FileMailer struct {
+    Dir  string
+    From string
+}
+
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+    now := time.Now()
+    body, err := format(m.From, msg, now)
+    if err != nil {
+        return err
+    }
+
+    if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+        return err
+    }
+
+    suffix := make([]byte, 4)
+    if _, err := rand.Read(suffix); err != nil {
+        return err
+    }
+    name := now.UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix) + ".eml"
+    return os.WriteFile(filepath.Join(m.Dir, name), body, 0o600)
+}
+
+// LogMailer logs each message instead of sending it.
+type LogMailer struct {
+    From string
+}
+
+func (m *LogMailer) Send(_ context.Context, msg Message) error {
+    body, err := format(m.From, msg, time.Now())
+    if err != nil {
+        return err
+    }
+    log.Printf("mail to %s:\n%s", msg.To, body)
+    return nil
+}
@@ -0,0 +1,68 @@
+package mailer
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "mime"
+    "net/mail"
+    "strings"
+    "time"
+)
+
+// maxAddressLength is the longest address SMTP can carry (RFC 5321).
+const maxAddressLength = 254
+
+var ErrInvalidAddress = errors.New("invalid email address")
+
+// ValidateAddress reports whether addr is a bare RFC 5322 address such as
+// jo@example.com. Display names, comments and angle brackets are rejected.
+func ValidateAddress(addr string) error {
+    if addr == "" || len(addr) > maxAddressLength {
+        return ErrInvalidAddress
+    }
+    parsed, err := mail.ParseAddress(addr)
+    if err != nil || parsed.Name != "" {
+        return ErrInvalidAddress
+    }
+    // Re-rendering catches anything ParseAddress tolerated around the
+    // address itself, such as whitespace or comments.
+    if (&mail.Address{Address: parsed.Address}).String() != "<"+addr+">" {
+        return ErrInvalidAddress
+    }
+    return nil
+}
+
+// Message is a plain text email.
+type Message struct {
+    To      string
+    Subject string
+    Body    string
+}
+
+// Mailer delivers email.
+type Mailer interface {
+    Send(ctx context.Context, msg Message) error
+}
+
+// format renders msg as an RFC 5322 message from from.
+func format(from string, msg Message, now time.Time) ([]byte, error) {
+    if err := ValidateAddress(msg.To); err != nil {
+        return nil, fmt.Errorf("%w: %q", err, msg.To)
+    }
+    if strings.ContainsAny(msg.Subject, "\r\n") {
+        return nil, errors.New("subject must be a single line")
+    }
+
+    var b bytes.Buffer
+    fmt.Fprintf(&b, "From: %s\r\n", from)
+    fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+    fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+    fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+    b.WriteString("MIME-Version: 1.0\r\n")
+    b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+    b.WriteString("\r\n")
+    b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+    return b.Bytes(), nil
+}
@@ -0,0 +1,151 @@
+package mailer
+
+import (
+    "context"
+    "net"
+    "net/textproto"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestValidateAddress(t *testing.T) {
+    tests := []struct {
+        addr string
+        ok   bool
+    }{
+        {"jo@example.com", true},
+        {"jo.smith+chirpy@mail.example.co.uk", true},
+        {`"jo smith"@example.com`, true},
+        {"", false},
+        {"jo", false},
+        {"jo@", false},
+        {"@example.com", false},
+        {"jo@@example.com", false},
+        {"Jo <jo@example.com>", false},
+        {" jo@example.com", false},
+        {"jo@example.com\r\nBcc: x@example.com", false},
+        {strings.Repeat("a", 250) + "@x.io", false},
+    }
+
+    for _, tt := range tests {
+        err := ValidateAddress(tt.addr)
+        if tt.ok {
+            assert.NoError(t, err, tt.addr)
+        } else {
+            assert.ErrorIs(t, err, ErrInvalidAddress, tt.addr)
+        }
+    }
+}
+
+func TestFormat(t *testing.T) {
+    now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+    body, err := format("no-reply@chirpy.example", Message{
+        To:      "jo@example.com",
+        Subject: "Vérifiez",
+        Body:    "line one\nline two",
+    }, now)
+    require.NoError(t, err)
+
+    s := string(body)
+    assert.Contains(t, s, "To: jo@example.com\r\n")
+    assert.Contains(t, s, "Subject: =?utf-8?q?V=C3=A9rifiez?=\r\n")
+    assert.True(t, strings.HasSuffix(s, "\r\n\r\nline one\r\nline two"))
+
+    _, err = format("no-reply@chirpy.example", Message{To: "jo@example.com", Subject: "a\r\nBcc: x@example.com"}, now)
+    assert.Error(t, err)
+}
+
+func TestFileMailer(t *testing.T) {
+    dir := filepath.Join(t.TempDir(), "mail")
+    m := &FileMailer{Dir: dir, From: "no-reply@chirpy.example"}
+
+    require.NoError(t, m.Send(context.Background(), Message{To: "jo@example.com", Subject: "Hi", Body: "hello"}))
+
+    files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+    require.NoError(t, err)
+    require.Len(t, files, 1)
+
+    content, err := os.ReadFile(files[0])
+    require.NoError(t, err)
+    assert.Contains(t, string(content), "Subject: Hi\r\n")
+    assert.Contains(t, string(content), "hello")
+}
+
+// fakeRelay accepts one SMTP session on a local port, answering every
+// command with 250 (354 for DATA), and sends the message it received on the
+// returned channel.
+func fakeRelay(t *testing.T) (string, <-chan string) {
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    require.NoError(t, err)
+    t.Cleanup(func() { ln.Close() })
+
+    received := make(chan string, 1)
+    go func() {
+        conn, err := ln.Accept()
+        if err != nil {
+            return
+        }
+        defer conn.Close()
+
+        tp := textproto.NewConn(conn)
+        tp.PrintfLine("220 localhost ready")
+        for {
+            line, err := tp.ReadLine()
+            if err != nil {
+                return
+            }
+            switch {
+            case strings.HasPrefix(line, "DATA"):
+                tp.PrintfLine("354 go ahead")
+                data, err := tp.ReadDotBytes()
+                if err != nil {
+                    return
+                }
+                received <- string(data)
+                tp.PrintfLine("250 queued")
+            case strings.HasPrefix(line, "QUIT"):
+                tp.PrintfLine("221 bye")
+                return
+            default:
+                tp.PrintfLine("250 ok")
+            }
+        }
+    }()
+    return ln.Addr().String(), received
+}
+
+func TestSMTPMailer(t *testing.T) {
+    addr, received := fakeRelay(t)
+    m := &SMTPMailer{Addr: addr, From: "no-reply@chirpy.example"}
+
+    require.NoError(t, m.Send(context.Background(), Message{To: "jo@example.com", Subject: "Hi", Body: "hello"}))
+    assert.Contains(t, <-received, "Subject: Hi\n")
+}
+
+func TestSMTPMailer_Timeout(t *testing.T) {
+    // A relay that accepts the connection but never greets.
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    require.NoError(t, err)
+    defer ln.Close()
+    go func() {
+        conn, err := ln.Accept()
+        if err == nil {
+            defer conn.Close()
+            time.Sleep(5 * time.Second)
+        }
+    }()
+
+    m := &SMTPMailer{Addr: ln.Addr().String(), From: "no-reply@chirpy.example"}
+    ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+    defer cancel()
+
+    start := time.Now()
+    assert.Error(t, m.Send(ctx, Message{To: "jo@example.com", Subject: "Hi", Body: "hello"}))
+    assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,97 @@
+package mailer
+
+import (
+    "context"
+    "crypto/tls"
+    "fmt"
+    "net"
+    "net/mail"
+    "net/smtp"
+    "time"
+)
+
+// defaultSMTPTimeout bounds a delivery whose context has no deadline, so a
+// relay that stops answering can't hold the caller forever.
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPMailer sends mail through an SMTP relay. Username and Password are
+// optional; when set, PLAIN auth is used, which net/smtp only allows over
+// TLS or to localhost.
+type SMTPMailer struct {
+    Addr     string
+    From     string
+    Username string
+    Password string
+}
+
+// Send delivers msg the way smtp.SendMail does, upgrading to TLS when the
+// relay offers STARTTLS, but gives up once ctx is done.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+    from, err := mail.ParseAddress(m.From)
+    if err != nil {
+        return fmt.Errorf("sender %q: %w", m.From, err)
+    }
+
+    body, err := format(from.String(), msg, time.Now())
+    if err != nil {
+        return err
+    }
+
+    host, _, err := net.SplitHostPort(m.Addr)
+    if err != nil {
+        return fmt.Errorf("smtp address %q: %w", m.Addr, err)
+    }
+
+    deadline, ok := ctx.Deadline()
+    if !ok {
+        deadline = time.Now().Add(defaultSMTPTimeout)
+    }
+
+    var dialer net.Dialer
+    conn, err := dialer.DialContext(ctx, "tcp", m.Addr)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+    if err := conn.SetDeadline(deadline); err != nil {
+        return err
+    }
+    // Cancelling ctx aborts whatever command is in flight.
+    stop := context.AfterFunc(ctx, func() { conn.Close() })
+    defer stop()
+
+    c, err := smtp.NewClient(conn, host)
+    if err != nil {
+        return err
+    }
+    defer c.Close()
+
+    if ok, _ := c.Extension("STARTTLS"); ok {
+        if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+            return err
+        }
+    }
+    if m.Username != "" {
+        if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, host)); err != nil {
+            return err
+        }
+    }
+
+    if err := c.Mail(from.Address); err != nil {
+        return err
+    }
+    if err := c.Rcpt(msg.To); err != nil {
+        return err
+    }
+    w, err := c.Data()
+    if err != nil {
+        return err
+    }
+    if _, err := w.Write(body); err != nil {
+        return err
+    }
+    if err := w.Close(); err != nil {
+        return err
+    }
+    return c.Quit()
+}
@@ -0,0 +1,103 @@
+package moderation
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+)
+
+// FileStore keeps the term list in a plain text file with one term per line.
+// Blank lines and lines starting with '#' are ignored.
+type FileStore struct {
+    Path string
+
+    mu sync.Mutex
+}
+
+func NewFileStore(path string) *FileStore {
+    return &FileStore{Path: path}
+}
+
+func (s *FileStore) ListTerms(_ context.Context) ([]string, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.read()
+}
+
+func (s *FileStore) AddTerm(_ context.Context, term string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    terms, err := s.read()
+    if err != nil {
+        return err
+    }
+    for _, t := range terms {
+        if t == term {
+            return nil
+        }
+    }
+    return s.write(append(terms, term))
+}
+
+func (s *FileStore) RemoveTerm(_ context.Context, term string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    terms, err := s.read()
+    if err != nil {
+        return err
+    }
+
+    kept := terms[:0]
+    for _, t := range terms {
+        if t != term {
+            kept = append(kept, t)
+        }
+    }
+    return s.write(kept)
+}
+
+func (s *FileStore) read() ([]string, error) {
+    file, err := os.Open(s.Path)
+    if errors.Is(err, os.ErrNotExist) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    var terms []string
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        terms = append(terms, line)
+    }
+    return terms, scanner.Err()
+}
+
+// write replaces the file atomically so a crash never leaves it half written.
+func (s *FileStore) write(terms []string) error {
+    tmp, err := os.CreateTemp(filepath.Dir(s.Path), ".moderation-*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+
+    if _, err := tmp.WriteString(strings.Join(terms, "\n") + "\n"); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), s.Path)
+}
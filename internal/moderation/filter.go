@@ -0,0 +1,193 @@
+package moderation
+
+import (
+    "context"
+    "errors"
+    "sort"
+    "strings"
+    "sync"
+    "unicode"
+    "unicode/utf8"
+)
+
+var ErrInvalidTerm = errors.New("term must be a single word")
+
+// Store persists the banned term list.
+type Store interface {
+    ListTerms(ctx context.Context) ([]string, error)
+    AddTerm(ctx context.Context, term string) error
+    RemoveTerm(ctx context.Context, term string) error
+}
+
+// Filter masks banned words in chirp bodies. Terms can be added and removed
+// while the server is running; changes are written through to the Store.
+type Filter struct {
+    store Store
+
+    mu    sync.RWMutex
+    terms map[string]struct{}
+}
+
+// NewFilter creates a filter and loads its terms from store.
+func NewFilter(ctx context.Context, store Store) (*Filter, error) {
+    f := &Filter{store: store}
+    if err := f.Reload(ctx); err != nil {
+        return nil, err
+    }
+    return f, nil
+}
+
+// Reload replaces the in-memory term list with the one in the store.
+func (f *Filter) Reload(ctx context.Context) error {
+    stored, err := f.store.ListTerms(ctx)
+    if err != nil {
+        return err
+    }
+
+    terms := make(map[string]struct{}, len(stored))
+    for _, term := range stored {
+        if key := Normalize(term); key != "" {
+            terms[key] = struct{}{}
+        }
+    }
+
+    f.mu.Lock()
+    f.terms = terms
+    f.mu.Unlock()
+    return nil
+}
+
+// Terms returns the normalized banned terms in sorted order.
+func (f *Filter) Terms() []string {
+    f.mu.RLock()
+    defer f.mu.RUnlock()
+
+    terms := make([]string, 0, len(f.terms))
+    for term := range f.terms {
+        terms = append(terms, term)
+    }
+    sort.Strings(terms)
+    return terms
+}
+
+// Add bans a term and returns its normalized form.
+func (f *Filter) Add(ctx context.Context, term string) (string, error) {
+    key, err := normalizeTerm(term)
+    if err != nil {
+        return "", err
+    }
+
+    if err := f.store.AddTerm(ctx, key); err != nil {
+        return "", err
+    }
+
+    f.mu.Lock()
+    f.terms[key] = struct{}{}
+    f.mu.Unlock()
+    return key, nil
+}
+
+// Remove lifts the ban on a term.
+func (f *Filter) Remove(ctx context.Context, term string) error {
+    key, err := normalizeTerm(term)
+    if err != nil {
+        return err
+    }
+
+    if err := f.store.RemoveTerm(ctx, key); err != nil {
+        return err
+    }
+
+    f.mu.Lock()
+    delete(f.terms, key)
+    f.mu.Unlock()
+    return nil
+}
+
+// Censor replaces every banned word in body with asterisks, one per
+// character, so the masked text keeps its shape. Only whole words match:
+// banning "fornax" leaves "fornaxes" alone.
+func (f *Filter) Censor(body string) string {
+    f.mu.RLock()
+    defer f.mu.RUnlock()
+
+    if len(f.terms) == 0 {
+        return body
+    }
+
+    var b strings.Builder
+    b.Grow(len(body))
+
+    i := 0
+    for i < len(body) {
+        r, size := utf8.DecodeRuneInString(body[i:])
+        if !isWordRune(r) {
+            b.WriteString(body[i : i+size])
+            i += size
+            continue
+        }
+
+        end := i
+        for end < len(body) {
+            r, size := utf8.DecodeRuneInString(body[end:])
+            if !isWordRune(r) {
+                break
+            }
+            end += size
+        }
+
+        b.WriteString(f.censorWord(body[i:end]))
+        i = end
+    }
+
+    return b.String()
+}
+
+func (f *Filter) censorWord(word string) string {
+    if _, ok := f.terms[Normalize(word)]; ok {
+        return mask(word)
+    }
+
+    // Leading or trailing symbols are usually punctuation ("$fornax$" or an
+    // @mention), so retry with them stripped before giving up.
+    core := strings.Trim(word, leetSymbols)
+    if core == "" || core == word {
+        return word
+    }
+    if _, ok := f.terms[Normalize(core)]; !ok {
+        return word
+    }
+
+    start := strings.Index(word, core)
+    return word[:start] + mask(core) + word[start+len(core):]
+}
+
+func mask(word string) string {
+    var b strings.Builder
+    for _, r := range word {
+        // Combining marks belong to the previous character.
+        if unicode.Is(unicode.Mn, r) {
+            continue
+        }
+        b.WriteByte('*')
+    }
+    return b.String()
+}
+
+func normalizeTerm(term string) (string, error) {
+    term = strings.TrimSpace(term)
+    if term == "" {
+        return "", ErrInvalidTerm
+    }
+    for _, r := range term {
+        if !isWordRune(r) {
+            return "", ErrInvalidTerm
+        }
+    }
+    return Normalize(term), nil
+}
+
+func isWordRune(r rune) bool {
+    return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) ||
+        strings.ContainsRune(leetSymbols, r)
+}
@@ -0,0 +1,77 @@
+package moderation
+
+import (
+    "context"
+    "path/filepath"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+    terms []string
+}
+
+func (s *memoryStore) ListTerms(_ context.Context) ([]string, error) { return s.terms, nil }
+
+func (s *memoryStore) AddTerm(_ context.Context, term string) error {
+    s.terms = append(s.terms, term)
+    return nil
+}
+
+func (s *memoryStore) RemoveTerm(_ context.Context, term string) error { return nil }
+
+func TestCensor(t *testing.T) {
+    f, err := NewFilter(context.Background(), &memoryStore{terms: []string{"kerfuffle", "sharbert", "Fornax"}})
+    require.NoError(t, err)
+
+    tests := []struct {
+        name string
+        body string
+        want string
+    }{
+        {"clean", "I had something interesting for breakfast", "I had something interesting for breakfast"},
+        {"mask keeps length", "This is a kerfuffle opinion", "This is a ********* opinion"},
+        {"case folding", "KERFUFFLE and Sharbert", "********* and ********"},
+        {"whole words only", "fornaxes are not fornax", "fornaxes are not ******"},
+        {"punctuation", "fornax! (sharbert)", "******! (********)"},
+        {"leetspeak", "k3rfuffl3 5harb3rt", "********* ********"},
+        {"leet symbols", "$harbert", "********"},
+        {"trailing symbol", "fornax$", "******$"},
+        {"diacritics", "kérfüffle", "*********"},
+        {"combining marks", "forna\u0301x", "******"},
+        {"unicode neighbours", "ünïcode fornax ünïcode", "ünïcode ****** ünïcode"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            assert.Equal(t, tt.want, f.Censor(tt.body))
+        })
+    }
+}
+
+func TestFilter_AddRemove(t *testing.T) {
+    ctx := context.Background()
+    store := NewFileStore(filepath.Join(t.TempDir(), "terms.txt"))
+
+    f, err := NewFilter(ctx, store)
+    require.NoError(t, err)
+    assert.Equal(t, "a fornax", f.Censor("a fornax"))
+
+    key, err := f.Add(ctx, "  Forn4x ")
+    require.NoError(t, err)
+    assert.Equal(t, "fornax", key)
+    assert.Equal(t, "a ******", f.Censor("a fornax"))
+
+    stored, err := store.ListTerms(ctx)
+    require.NoError(t, err)
+    assert.Equal(t, []string{"fornax"}, stored)
+
+    require.NoError(t, f.Remove(ctx, "FORNAX"))
+    assert.Equal(t, "a fornax", f.Censor("a fornax"))
+    assert.Empty(t, f.Terms())
+
+    _, err = f.Add(ctx, "two words")
+    assert.ErrorIs(t, err, ErrInvalidTerm)
+}
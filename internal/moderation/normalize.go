@@ -0,0 +1,51 @@
+package moderation
+
+import (
+    "strings"
+    "unicode"
+
+    "golang.org/x/text/cases"
+    "golang.org/x/text/runes"
+    "golang.org/x/text/transform"
+    "golang.org/x/text/unicode/norm"
+)
+
+// leetSymbols are the non-alphanumeric characters that stand in for letters.
+const leetSymbols = "@$"
+
+var leet = map[rune]rune{
+    '0': 'o',
+    '1': 'i',
+    '3': 'e',
+    '4': 'a',
+    '5': 's',
+    '7': 't',
+    '8': 'b',
+    '@': 'a',
+    '$': 's',
+}
+
+var folder = cases.Fold()
+
+// Normalize maps a word to the form terms are compared in: diacritics are
+// stripped, case is folded and leetspeak digits and symbols become letters,
+// so "Kérfuffl3" and "kerfuffle" normalize the same.
+func Normalize(word string) string {
+    stripper := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+    stripped, _, err := transform.String(stripper, word)
+    if err != nil {
+        stripped = word
+    }
+
+    folded := folder.String(stripped)
+
+    var b strings.Builder
+    b.Grow(len(folded))
+    for _, r := range folded {
+        if l, ok := leet[r]; ok {
+            r = l
+        }
+        b.WriteRune(r)
+    }
+    return b.String()
+}
@@ -0,0 +1,75 @@
+package passwordpolicy
+
+import (
+    "bufio"
+    "context"
+    "crypto/sha1"
+    "encoding/hex"
+    "fmt"
+    "os"
+    "slices"
+    "strings"
+)
+
+// prefixLength is how many hex digits of the SHA-1 are used to pick a range,
+// as in the Pwned Passwords range API.
+const prefixLength = 5
+
+// RangeSource returns the SHA-1 suffixes (the 35 uppercase hex digits after
+// prefix) of breached passwords whose hash starts with prefix. Only the
+// prefix leaves the caller, so a remote source never sees the full hash.
+type RangeSource interface {
+    Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+// IsBreached reports whether password appears in src.
+func IsBreached(ctx context.Context, src RangeSource, password string) (bool, error) {
+    sum := sha1.Sum([]byte(password))
+    digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+    suffixes, err := src.Range(ctx, digest[:prefixLength])
+    if err != nil {
+        return false, err
+    }
+    return slices.Contains(suffixes, digest[prefixLength:]), nil
+}
+
+// BreachedList is a RangeSource held in memory.
+type BreachedList struct {
+    ranges map[string][]string
+}
+
+// LoadBreachedList reads a file of SHA-1 hashes, one per line, in the Pwned
+// Passwords download format: the hex digest optionally followed by
+// ":count". Blank lines and lines starting with '#' are ignored.
+func LoadBreachedList(path string) (*BreachedList, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    list := &BreachedList{ranges: make(map[string][]string)}
+    scanner := bufio.NewScanner(f)
+    for n := 1; scanner.Scan(); n++ {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        digest, _, _ := strings.Cut(line, ":")
+        if _, err := hex.DecodeString(digest); err != nil || len(digest) != 2*sha1.Size {
+            return nil, fmt.Errorf("%s:%d: not a SHA-1 hex digest", path, n)
+        }
+        digest = strings.ToUpper(digest)
+        prefix := digest[:prefixLength]
+        list.ranges[prefix] = append(list.ranges[prefix], digest[prefixLength:])
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+    return list, nil
+}
+
+func (l *BreachedList) Range(_ context.Context, prefix string) ([]string, error) {
+    return l.ranges[strings.ToUpper(prefix)], nil
+}
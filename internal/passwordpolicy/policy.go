@@ -0,0 +1,83 @@
+package passwordpolicy
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "unicode/utf8"
+)
+
+// Violation codes reported in Error.
+const (
+    CodeTooShort     = "too_short"
+    CodeTooLong      = "too_long"
+    CodeMatchesEmail = "matches_email"
+    CodeBreached     = "breached"
+)
+
+// Violation is one reason a password was rejected.
+type Violation struct {
+    Code    string `json:"code"`
+    Message string `json:"message"`
+}
+
+// Error lists every rule a password broke.
+type Error struct {
+    Violations []Violation
+}
+
+func (e *Error) Error() string {
+    msgs := make([]string, len(e.Violations))
+    for i, v := range e.Violations {
+        msgs[i] = v.Message
+    }
+    return "password rejected: " + strings.Join(msgs, "; ")
+}
+
+// Policy decides which passwords may be set. MinLength counts characters;
+// MaxLength counts bytes, since that is what hashing pays for. Breached is
+// optional.
+type Policy struct {
+    MinLength int
+    MaxLength int
+    Breached  RangeSource
+}
+
+// Check returns an *Error listing every rule password breaks, or nil. email
+// is the account's address, which may not double as its password. Other
+// errors come from the breached password source.
+func (p Policy) Check(ctx context.Context, password, email string) error {
+    var violations []Violation
+    add := func(code, format string, args ...any) {
+        violations = append(violations, Violation{Code: code, Message: fmt.Sprintf(format, args...)})
+    }
+
+    if utf8.RuneCountInString(password) < p.MinLength {
+        add(CodeTooShort, "must be at least %d characters", p.MinLength)
+    }
+    if len(password) > p.MaxLength {
+        add(CodeTooLong, "must be at most %d bytes", p.MaxLength)
+    }
+    if email != "" {
+        local, _, _ := strings.Cut(email, "@")
+        if strings.EqualFold(password, email) || strings.EqualFold(password, local) {
+            add(CodeMatchesEmail, "must not be your email address")
+        }
+    }
+
+    // Overlong passwords are not worth hashing just to look them up.
+    if p.Breached != nil && len(password) <= p.MaxLength {
+        breached, err := IsBreached(ctx, p.Breached, password)
+        if err != nil {
+            return err
+        }
+        if breached {
+            add(CodeBreached, "appears in a known data breach; choose a different one")
+        }
+    }
+
+    if len(violations) > 0 {
+        return &Error{Violations: violations}
+    }
+    return nil
+}
@@ -0,0 +1,86 @@
+package passwordpolicy
+
+import (
+    "context"
+    "crypto/sha1"
+    "encoding/hex"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func sha1Hex(s string) string {
+    sum := sha1.Sum([]byte(s))
+    return hex.EncodeToString(sum[:])
+}
+
+func writeList(t *testing.T, lines ...string) string {
+    t.Helper()
+    path := filepath.Join(t.TempDir(), "breached.txt")
+    require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600))
+    return path
+}
+
+func violationCodes(err error) []string {
+    var codes []string
+    if perr, ok := err.(*Error); ok {
+        for _, v := range perr.Violations {
+            codes = append(codes, v.Code)
+        }
+    }
+    return codes
+}
+
+func TestPolicy_Check(t *testing.T) {
+    list, err := LoadBreachedList(writeList(t,
+        "# top passwords",
+        strings.ToUpper(sha1Hex("password123"))+":2254650",
+        sha1Hex("letmein!!"),
+    ))
+    require.NoError(t, err)
+    policy := Policy{MinLength: 8, MaxLength: 64, Breached: list}
+
+    tests := []struct {
+        name     string
+        password string
+        want     []string
+    }{
+        {"ok", "correct horse battery", nil},
+        {"empty", "", []string{CodeTooShort}},
+        {"short", "abc", []string{CodeTooShort}},
+        {"counts characters", "äöüäöüäö", nil},
+        {"long", strings.Repeat("a", 65), []string{CodeTooLong}},
+        {"email", "Jo.Smith@Example.com", []string{CodeMatchesEmail}},
+        {"email local part", "jo.smith", []string{CodeMatchesEmail}},
+        {"breached", "password123", []string{CodeBreached}},
+        {"breached lowercase hex", "letmein!!", []string{CodeBreached}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := policy.Check(context.Background(), tt.password, "jo.smith@example.com")
+            if tt.want == nil {
+                assert.NoError(t, err)
+                return
+            }
+            assert.Equal(t, tt.want, violationCodes(err))
+        })
+    }
+}
+
+func TestPolicy_CheckReportsEveryViolation(t *testing.T) {
+    policy := Policy{MinLength: 12, MaxLength: 64}
+
+    err := policy.Check(context.Background(), "jo", "jo@example.com")
+    assert.Equal(t, []string{CodeTooShort, CodeMatchesEmail}, violationCodes(err))
+    assert.EqualError(t, err, "password rejected: must be at least 12 characters; must not be your email address")
+}
+
+func TestLoadBreachedList_Invalid(t *testing.T) {
+    _, err := LoadBreachedList(writeList(t, sha1Hex("a"), "not-a-hash"))
+    assert.ErrorContains(t, err, ":2: not a SHA-1 hex digest")
+}
@@ -0,0 +1,45 @@
+package search
+
+import (
+    "html"
+    "strings"
+)
+
+// ts_headline marks matches with these control characters rather than
+// HTML, so the body can be escaped after highlighting.
+const (
+    startSel = "\x02"
+    stopSel  = "\x03"
+)
+
+// HeadlineOptions are the ts_headline options Highlight expects.
+const HeadlineOptions = "StartSel=" + startSel + ", StopSel=" + stopSel + ", MaxFragments=2"
+
+// Highlight turns a snippet made by ts_headline with HeadlineOptions into
+// HTML: the text is escaped and matches are wrapped in <mark> tags. Stray
+// markers, such as ones typed into the chirp itself, are dropped.
+func Highlight(headline string) string {
+    var b strings.Builder
+    open := false
+    for {
+        i := strings.IndexAny(headline, startSel+stopSel)
+        if i < 0 {
+            break
+        }
+        b.WriteString(html.EscapeString(headline[:i]))
+        switch {
+        case headline[i:i+1] == startSel && !open:
+            b.WriteString("<mark>")
+            open = true
+        case headline[i:i+1] == stopSel && open:
+            b.WriteString("</mark>")
+            open = false
+        }
+        headline = headline[i+1:]
+    }
+    b.WriteString(html.EscapeString(headline))
+    if open {
+        b.WriteString("</mark>")
+    }
+    return b.String()
+}
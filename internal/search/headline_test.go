@@ -0,0 +1,28 @@
+package search
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+    tests := []struct {
+        name     string
+        headline string
+        want     string
+    }{
+        {"plain", "no matches here", "no matches here"},
+        {"match", "say \x02hello\x03 world", "say <mark>hello</mark> world"},
+        {"text is escaped", "\x02fish\x03 & <chips>", "<mark>fish</mark> &amp; &lt;chips&gt;"},
+        {"ampersand between matches", "\x02fish\x03 & \x02chips\x03", "<mark>fish</mark> &amp; <mark>chips</mark>"},
+        {"match next to ampersand", "\x02amp\x03&amp", "<mark>amp</mark>&amp;amp"},
+        {"stray markers", "\x03a \x02b\x02 c", "a <mark>b c</mark>"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            assert.Equal(t, tt.want, Highlight(tt.headline))
+        })
+    }
+}
@@ -0,0 +1,58 @@
+package search
+
+import (
+    "errors"
+    "strings"
+    "unicode"
+)
+
+var ErrEmptyQuery = errors.New("search query is empty")
+
+// BuildTSQuery turns user input into a Postgres to_tsquery expression.
+// Bare words are ANDed together, "quoted phrases" must match in order and
+// a trailing * (e.g. chirp*) matches by prefix. Anything that is not a letter
+// or digit is dropped so the result is always valid tsquery syntax.
+func BuildTSQuery(q string) (string, error) {
+    var terms []string
+
+    for i, part := range strings.Split(q, `"`) {
+        // Odd parts sit between a pair of quotes.
+        if i%2 == 1 {
+            if phrase := joinPhrase(lexemes(part)); phrase != "" {
+                terms = append(terms, phrase)
+            }
+            continue
+        }
+
+        for _, word := range strings.Fields(part) {
+            prefix := strings.HasSuffix(word, "*")
+            words := lexemes(word)
+            if len(words) == 0 {
+                continue
+            }
+            if prefix {
+                words[len(words)-1] += ":*"
+            }
+            terms = append(terms, joinPhrase(words))
+        }
+    }
+
+    if len(terms) == 0 {
+        return "", ErrEmptyQuery
+    }
+
+    return strings.Join(terms, " & "), nil
+}
+
+func lexemes(s string) []string {
+    return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+}
+
+func joinPhrase(words []string) string {
+    if len(words) > 1 {
+        return "(" + strings.Join(words, " <-> ") + ")"
+    }
+    return strings.Join(words, "")
+}
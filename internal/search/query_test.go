@@ -0,0 +1,36 @@
+package search
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestBuildTSQuery(t *testing.T) {
+    tests := []struct {
+        name  string
+        input string
+        want  string
+    }{
+        {"single word", "chirp", "chirp"},
+        {"words are anded", "hello World", "hello & world"},
+        {"phrase", `"hello world"`, "(hello <-> world)"},
+        {"prefix", "chirp*", "chirp:*"},
+        {"mixed", `go "small talk" chir*`, "go & (small <-> talk) & chir:*"},
+        {"operators are stripped", "a&b | !c", "(a <-> b) & c"},
+        {"unterminated quote", `"hello world`, "(hello <-> world)"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := BuildTSQuery(tt.input)
+            assert.NoError(t, err)
+            assert.Equal(t, tt.want, got)
+        })
+    }
+}
+
+func TestBuildTSQuery_Empty(t *testing.T) {
+    _, err := BuildTSQuery(`  "" * !! `)
+    assert.ErrorIs(t, err, ErrEmptyQuery)
+}
@@ -0,0 +1,116 @@
+package throttle
+
+import (
+    "sync"
+    "time"
+)
+
+// Policy describes how failed attempts are slowed down. The first
+// FreeAttempts failures cost nothing; after that each failure doubles the
+// wait before the next attempt, starting at BaseDelay and capped at MaxDelay.
+// From LockoutAfter failures on, attempts are refused for LockoutDuration.
+// Failures older than ResetAfter are forgotten.
+type Policy struct {
+    FreeAttempts    int
+    BaseDelay       time.Duration
+    MaxDelay        time.Duration
+    LockoutAfter    int
+    LockoutDuration time.Duration
+    ResetAfter      time.Duration
+}
+
+// Delay returns how long to wait after the given number of failures.
+func (p Policy) Delay(failures int) time.Duration {
+    if failures >= p.LockoutAfter {
+        return p.LockoutDuration
+    }
+    if failures < p.FreeAttempts {
+        return 0
+    }
+
+    delay := p.BaseDelay
+    for i := p.FreeAttempts; i < failures && delay < p.MaxDelay; i++ {
+        delay *= 2
+    }
+    return min(delay, p.MaxDelay)
+}
+
+// RetryAfter returns how long the caller must still wait, given the number
+// of failures and when the last one happened. Zero means go ahead.
+func (p Policy) RetryAfter(failures int, lastFailure, now time.Time) time.Duration {
+    if failures == 0 || now.Sub(lastFailure) >= p.ResetAfter {
+        return 0
+    }
+    return max(lastFailure.Add(p.Delay(failures)).Sub(now), 0)
+}
+
+// Locked reports whether failures is enough to lock the key out.
+func (p Policy) Locked(failures int) bool {
+    return failures >= p.LockoutAfter
+}
+
+type entry struct {
+    failures    int
+    lastFailure time.Time
+}
+
+// Tracker counts failures per key in memory, e.g. per client IP. State is
+// local to the process.
+type Tracker struct {
+    policy Policy
+    now    func() time.Time
+
+    mu      sync.Mutex
+    entries map[string]entry
+}
+
+func NewTracker(policy Policy) *Tracker {
+    return &Tracker{policy: policy, now: time.Now, entries: make(map[string]entry)}
+}
+
+// RetryAfter returns how long key must wait before its next attempt.
+func (t *Tracker) RetryAfter(key string) time.Duration {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    e := t.entries[key]
+    return t.policy.RetryAfter(e.failures, e.lastFailure, t.now())
+}
+
+// Fail records a failed attempt for key.
+func (t *Tracker) Fail(key string) {
+    now := t.now()
+
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    e := t.entries[key]
+    if now.Sub(e.lastFailure) >= t.policy.ResetAfter {
+        e.failures = 0
+    }
+    e.failures++
+    e.lastFailure = now
+    t.entries[key] = e
+
+    t.prune(now)
+}
+
+// Reset forgets the failures recorded for key.
+func (t *Tracker) Reset(key string) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    delete(t.entries, key)
+}
+
+// prune drops forgotten entries once the map has grown, so a flood of
+// distinct keys cannot grow it without bound. Callers hold t.mu.
+func (t *Tracker) prune(now time.Time) {
+    if len(t.entries) < 10000 {
+        return
+    }
+    for key, e := range t.entries {
+        if now.Sub(e.lastFailure) >= t.policy.ResetAfter {
+            delete(t.entries, key)
+        }
+    }
+}
@@ -0,0 +1,76 @@
+package throttle
+
+import (
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+)
+
+var testPolicy = Policy{
+    FreeAttempts:    3,
+    BaseDelay:       time.Second,
+    MaxDelay:        time.Minute,
+    LockoutAfter:    10,
+    LockoutDuration: 15 * time.Minute,
+    ResetAfter:      24 * time.Hour,
+}
+
+func TestPolicy_Delay(t *testing.T) {
+    tests := []struct {
+        failures int
+        want     time.Duration
+    }{
+        {0, 0},
+        {2, 0},
+        {3, time.Second},
+        {4, 2 * time.Second},
+        {6, 8 * time.Second},
+        {9, time.Minute},
+        {10, 15 * time.Minute},
+        {50, 15 * time.Minute},
+    }
+
+    for _, tt := range tests {
+        assert.Equal(t, tt.want, testPolicy.Delay(tt.failures), "failures=%d", tt.failures)
+    }
+}
+
+func TestPolicy_RetryAfter(t *testing.T) {
+    now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+    assert.Equal(t, time.Duration(0), testPolicy.RetryAfter(0, time.Time{}, now))
+    assert.Equal(t, 6*time.Second, testPolicy.RetryAfter(6, now.Add(-2*time.Second), now))
+    assert.Equal(t, time.Duration(0), testPolicy.RetryAfter(6, now.Add(-time.Minute), now))
+    assert.Equal(t, 14*time.Minute, testPolicy.RetryAfter(10, now.Add(-time.Minute), now))
+    assert.Equal(t, time.Duration(0), testPolicy.RetryAfter(10, now.Add(-25*time.Hour), now), "old failures are forgotten")
+    assert.True(t, testPolicy.Locked(10))
+    assert.False(t, testPolicy.Locked(9))
+}
+
+func TestTracker(t *testing.T) {
+    now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+    tr := NewTracker(testPolicy)
+    tr.now = func() time.Time { return now }
+
+    for i := 0; i < 3; i++ {
+        assert.Zero(t, tr.RetryAfter("10.0.0.1"))
+        tr.Fail("10.0.0.1")
+    }
+    assert.Equal(t, time.Second, tr.RetryAfter("10.0.0.1"))
+    assert.Zero(t, tr.RetryAfter("10.0.0.2"), "keys are tracked separately")
+
+    now = now.Add(time.Second)
+    assert.Zero(t, tr.RetryAfter("10.0.0.1"))
+
+    tr.Reset("10.0.0.1")
+    tr.Fail("10.0.0.1")
+    assert.Zero(t, tr.RetryAfter("10.0.0.1"))
+
+    // A failure after the reset window starts counting from one again.
+    now = now.Add(25 * time.Hour)
+    for i := 0; i < 3; i++ {
+        tr.Fail("10.0.0.1")
+    }
+    assert.Equal(t, time.Second, tr.RetryAfter("10.0.0.1"))
+}
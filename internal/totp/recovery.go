@@ -0,0 +1,45 @@
+package totp
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+    "strings"
+)
+
+// recoveryAlphabet leaves out 0, 1, I and O, which are easily confused when
+// a code is copied by hand.
+const recoveryAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// RecoveryCodeCount is how many recovery codes are issued at a time.
+const RecoveryCodeCount = 10
+
+// GenerateRecoveryCodes returns n single-use codes like 7KQM-X2PD-9RTF.
+// Each carries 60 bits of entropy.
+func GenerateRecoveryCodes(n int) ([]string, error) {
+    codes := make([]string, n)
+    buf := make([]byte, 12)
+    for i := range codes {
+        if _, err := rand.Read(buf); err != nil {
+            return nil, err
+        }
+        var b strings.Builder
+        for j, c := range buf {
+            if j > 0 && j%4 == 0 {
+                b.WriteByte('-')
+            }
+            b.WriteByte(recoveryAlphabet[int(c)%len(recoveryAlphabet)])
+        }
+        codes[i] = b.String()
+    }
+    return codes, nil
+}
+
+// HashRecoveryCode returns the digest a recovery code is stored under.
+// Case, dashes and spaces are ignored so users may type it loosely. With 60
+// bits of entropy per code a plain SHA-256 is out of reach of brute force.
+func HashRecoveryCode(code string) string {
+    code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+    sum := sha256.Sum256([]byte(code))
+    return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,62 @@
+package totp
+
+import (
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/rand"
+    "encoding/base64"
+    "errors"
+    "fmt"
+
+    "github.com/google/uuid"
+)
+
+// KeySize is the length in bytes of a Sealer key (AES-256).
+const KeySize = 32
+
+// Sealer encrypts secrets for storage with AES-256-GCM. Each ciphertext is
+// bound to its user, so it cannot be copied into another user's row.
+type Sealer struct {
+    aead cipher.AEAD
+}
+
+// NewSealer returns a Sealer using key, which must be KeySize bytes.
+func NewSealer(key []byte) (*Sealer, error) {
+    if len(key) != KeySize {
+        return nil, fmt.Errorf("totp key must be %d bytes, got %d", KeySize, len(key))
+    }
+    block, err := aes.NewCipher(key)
+    if err != nil {
+        return nil, err
+    }
+    aead, err := cipher.NewGCM(block)
+    if err != nil {
+        return nil, err
+    }
+    return &Sealer{aead: aead}, nil
+}
+
+// Seal encrypts userID's secret.
+func (s *Sealer) Seal(userID uuid.UUID, secret string) (string, error) {
+    nonce := make([]byte, s.aead.NonceSize())
+    if _, err := rand.Read(nonce); err != nil {
+        return "", fmt.Errorf("totp nonce: %w", err)
+    }
+    sealed := s.aead.Seal(nonce, nonce, []byte(secret), userID[:])
+    return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a secret made by Seal for userID.
+func (s *Sealer) Open(userID uuid.UUID, stored string) (string, error) {
+    sealed, err := base64.RawStdEncoding.DecodeString(stored)
+    if err != nil || len(sealed) < s.aead.NonceSize() {
+        return "", errors.New("totp secret is malformed")
+    }
+
+    nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+    secret, err := s.aead.Open(nil, nonce, ciphertext, userID[:])
+    if err != nil {
+        return "", fmt.Errorf("totp secret: %w", err)
+    }
+    return string(secret), nil
+}
@@ -0,0 +1,107 @@
+package totp
+
+import (
+    "encoding/base32"
+    "net/url"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 key from the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238(t *testing.T) {
+    // The RFC lists 8 digit codes; ours are their last 6 digits.
+    tests := []struct {
+        unix int64
+        want string
+    }{
+        {59, "287082"},
+        {1111111109, "081804"},
+        {1111111111, "050471"},
+        {1234567890, "005924"},
+        {2000000000, "279037"},
+        {20000000000, "353130"},
+    }
+
+    for _, tt := range tests {
+        got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+        require.NoError(t, err)
+        assert.Equal(t, tt.want, got, "t=%d", tt.unix)
+    }
+}
+
+func TestVerify(t *testing.T) {
+    secret, err := GenerateSecret()
+    require.NoError(t, err)
+    now := time.Unix(1_800_000_000, 0)
+
+    code, err := Code(secret, Step(now))
+    require.NoError(t, err)
+
+    step, ok := Verify(secret, code, now)
+    assert.True(t, ok)
+    assert.Equal(t, Step(now), step)
+
+    _, ok = Verify(secret, code, now.Add(Period))
+    assert.True(t, ok, "one step of drift is tolerated")
+
+    _, ok = Verify(secret, code, now.Add(3*Period))
+    assert.False(t, ok)
+
+    _, ok = Verify(secret, "12345", now)
+    assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+    u, err := url.Parse(URI("chirpy", "jo@example.com", "JBSWY3DPEHPK3PXP"))
+    require.NoError(t, err)
+
+    assert.Equal(t, "otpauth", u.Scheme)
+    assert.Equal(t, "totp", u.Host)
+    assert.Equal(t, "/chirpy:jo@example.com", u.Path)
+    assert.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+    assert.Equal(t, "chirpy", u.Query().Get("issuer"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+    codes, err := GenerateRecoveryCodes(RecoveryCodeCount)
+    require.NoError(t, err)
+    require.Len(t, codes, RecoveryCodeCount)
+    assert.Regexp(t, `^[2-9A-HJ-NP-Z]{4}-[2-9A-HJ-NP-Z]{4}-[2-9A-HJ-NP-Z]{4}$`, codes[0])
+    assert.NotEqual(t, codes[0], codes[1])
+
+    assert.Equal(t, HashRecoveryCode("7KQM-X2PD-9RTF"), HashRecoveryCode("7kqm x2pd 9rtf"))
+    assert.NotEqual(t, HashRecoveryCode("7KQM-X2PD-9RTF"), HashRecoveryCode("7KQM-X2PD-9RTG"))
+}
+
+func TestSealer(t *testing.T) {
+    key := make([]byte, KeySize)
+    key[0] = 1
+    s, err := NewSealer(key)
+    require.NoError(t, err)
+    userID := uuid.New()
+
+    sealed, err := s.Seal(userID, "JBSWY3DPEHPK3PXP")
+    require.NoError(t, err)
+    assert.NotContains(t, sealed, "JBSWY3DPEHPK3PXP")
+
+    secret, err := s.Open(userID, sealed)
+    require.NoError(t, err)
+    assert.Equal(t, "JBSWY3DPEHPK3PXP", secret)
+
+    _, err = s.Open(uuid.New(), sealed)
+    assert.Error(t, err, "a secret is bound to its user")
+
+    other, err := NewSealer(make([]byte, KeySize))
+    require.NoError(t, err)
+    _, err = other.Open(userID, sealed)
+    assert.Error(t, err, "a different key cannot open it")
+
+    _, err = NewSealer([]byte("short"))
+    assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+    "context"
+    "net/http"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/database"
+)
+
+// attachLikes fills in like_count and liked_by_me for a batch of chirps with
+// a single query. viewerID may be uuid.Nil for anonymous requests.
+func (cfg *apiConfig) attachLikes(ctx context.Context, viewerID uuid.UUID, chirps []Chirp) error {
+    if len(chirps) == 0 {
+        return nil
+    }
+
+    ids := make([]uuid.UUID, 0, len(chirps))
+    for _, chirp := range chirps {
+        ids = append(ids, chirp.ID)
+    }
+
+    stats, err := cfg.DB.GetChirpLikeStats(ctx, database.GetChirpLikeStatsParams{
+        ViewerID: uuid.NullUUID{UUID: viewerID, Valid: viewerID != uuid.Nil},
+        ChirpIds: ids,
+    })
+    if err != nil {
+        return err
+    }
+
+    byChirp := make(map[uuid.UUID]database.GetChirpLikeStatsRow, len(stats))
+    for _, s := range stats {
+        byChirp[s.ChirpID] = s
+    }
+
+    for i := range chirps {
+        s := byChirp[chirps[i].ID]
+        chirps[i].LikeCount = s.LikeCount
+        chirps[i].LikedByMe = s.LikedByMe
+    }
+
+    return nil
+}
+
+func (cfg *apiConfig) likeChirpHandler(w http.ResponseWriter, r *http.Request) {
+    userID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return
+    }
+
+    chirpID, err := uuid.Parse(r.PathValue("chirpId"))
+    if err != nil {
+        http.Error(w, "Invalid chirp ID", http.StatusBadRequest)
+        return
+    }
+
+    chirp, err := cfg.DB.GetChirp(r.Context(), chirpID)
+    if err != nil || !visibleTo(chirp, userID) {
+        http.Error(w, "No such chirp", http.StatusNotFound)
+        return
+    }
+
+    err = cfg.DB.LikeChirp(r.Context(), database.LikeChirpParams{
+        UserID:  userID,
+        ChirpID: chirpID,
+    })
+    if err != nil {
+        http.Error(w, "Failed to like chirp", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unlikeChirpHandler(w http.ResponseWriter, r *http.Request) {
+    userID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return
+    }
+
+    chirpID, err := uuid.Parse(r.PathValue("chirpId"))
+    if err != nil {
+        http.Error(w, "Invalid chirp ID", http.StatusBadRequest)
+        return
+    }
+
+    err = cfg.DB.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+        UserID:  userID,
+        ChirpID: chirpID,
+    })
+    if err != nil {
+        http.Error(w, "Failed to unlike chirp", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
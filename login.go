@@ -0,0 +1,275 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "log"
+    "math"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/auth"
+    "github.com/danon29/chippy/internal/config"
+    "github.com/danon29/chippy/internal/database"
+    "github.com/danon29/chippy/internal/throttle"
+)
+
+// loginPolicies returns the throttling applied to failed logins per account
+// and per client IP. An IP may try several accounts, so it gets more room
+// before it is locked out.
+func loginPolicies(conf config.Auth) (account, ip throttle.Policy) {
+    account = throttle.Policy{
+        FreeAttempts:    3,
+        BaseDelay:       time.Second,
+        MaxDelay:        time.Minute,
+        LockoutAfter:    conf.MaxFailedLogins,
+        LockoutDuration: conf.LockoutDuration,
+        ResetAfter:      24 * time.Hour,
+    }
+    ip = account
+    ip.FreeAttempts = 2 * conf.MaxFailedLogins
+    ip.LockoutAfter = 5 * conf.MaxFailedLogins
+    ip.ResetAfter = time.Hour
+    return account, ip
+}
+
+func writeLoginFailed(w http.ResponseWriter) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusUnauthorized)
+    json.NewEncoder(w).Encode(struct{ Error string }{"Incorrect email or password"})
+}
+
+func writeTooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+    w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+    http.Error(w, "Too many failed login attempts, try again later", http.StatusTooManyRequests)
+}
+
+// loginParams is the body of POST /api/login. The first step sends Email
+// and Password; accounts with two-factor authentication then send the
+// ChallengeToken they got back together with a Code or a RecoveryCode.
+type loginParams struct {
+    Password string `json:"password"`
+    Email string `json:"email"`
+    ChallengeToken string `json:"challenge_token"`
+    Code           string `json:"code"`
+    RecoveryCode   string `json:"recovery_code"`
+}
+
+func (cfg *apiConfig) loginHandler(w http.ResponseWriter, r *http.Request) {
+    var p loginParams
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, "Invalid request", http.StatusBadRequest)
+        return
+    }
+
+    ip := clientIP(r)
+    if wait := cfg.loginThrottle.RetryAfter(ip); wait > 0 {
+        writeTooManyAttempts(w, wait)
+        return
+    }
+
+    if p.ChallengeToken != "" {
+        cfg.completeTwoFactorLogin(w, r, ip, p)
+        return
+    }
+
+    user, err := cfg.DB.FindUser(r.Context(), p.Email)
+    if errors.Is(err, sql.ErrNoRows) {
+        // Unknown emails get the same backoff, lockout and hashing cost as
+        // real accounts, so neither the responses nor their timing reveal
+        // whether the email has an account.
+        key := strings.ToLower(p.Email)
+        if wait := cfg.unknownEmailThrottle.RetryAfter(key); wait > 0 {
+            writeTooManyAttempts(w, wait)
+            return
+        }
+        auth.CheckPasswordHash(p.Password, cfg.dummyPasswordHash)
+        cfg.unknownEmailThrottle.Fail(key)
+        cfg.loginThrottle.Fail(ip)
+        writeLoginFailed(w)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Unknown error", http.StatusInternalServerError)
+        return
+    }
+
+    now := time.Now()
+    wait := cfg.loginPolicy.RetryAfter(int(user.FailedLoginCount), user.LastFailedLoginAt.Time, now)
+    if wait > 0 {
+        writeTooManyAttempts(w, wait)
+        return
+    }
+
+    isValidPassword, err := auth.CheckPasswordHash(p.Password, user.HashedPassword)
+    if err != nil {
+        http.Error(w, "Unknown error", http.StatusInternalServerError)
+        return
+    }
+
+    if !isValidPassword {
+        cfg.recordFailedLogin(r.Context(), user.ID, ip, now)
+        writeLoginFailed(w)
+        return
+    }
+
+    twoFactor, err := cfg.twoFactorEnabled(r.Context(), user.ID)
+    if err != nil {
+        http.Error(w, "Unknown error", http.StatusInternalServerError)
+        return
+    }
+
+    // With two-factor authentication the count is only cleared once the
+    // second factor checks out; otherwise knowing the password would buy
+    // unlimited guesses at the code.
+    if !twoFactor && user.FailedLoginCount > 0 {
+        cfg.resetFailedLogins(r.Context(), user.ID)
+    }
+
+    if user.BannedAt.Valid {
+        http.Error(w, "Account suspended", http.StatusForbidden)
+        return
+    }
+
+    // Upgrade hashes made under an older cost policy while we have the
+    // plaintext. A failure here must not block the login.
+    if needsRehash, err := auth.NeedsRehash(user.HashedPassword, cfg.passwordParams); err == nil && needsRehash {
+        if hash, err := auth.HashPassword(p.Password, cfg.passwordParams); err == nil {
+            err = cfg.DB.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+                ID:             user.ID,
+                HashedPassword: hash,
+            })
+            if err != nil {
+                log.Printf("Error rehashing password for user %s: %v", user.ID, err)
+            }
+        }
+    }
+
+    if twoFactor {
+        challenge, err := auth.MakeChallengeToken(user.ID, cfg.jwtKeys, twoFactorChallengeTTL)
+        if err != nil {
+            http.Error(w, "Unknown error", http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(struct {
+            TwoFactorRequired bool   `json:"two_factor_required"`
+            ChallengeToken    string `json:"challenge_token"`
+        }{true, challenge})
+        return
+    }
+
+    cfg.issueLoginTokens(w, r, user)
+}
+
+// recordFailedLogin counts a wrong password or code against both the
+// account and the client IP.
+func (cfg *apiConfig) recordFailedLogin(ctx context.Context, userID uuid.UUID, ip string, now time.Time) {
+    cfg.loginThrottle.Fail(ip)
+    failures, err := cfg.DB.RecordFailedLogin(ctx, database.RecordFailedLoginParams{
+        WindowStart: now.Add(-cfg.loginPolicy.ResetAfter),
+        ID:          userID,
+    })
+    if err != nil {
+        log.Printf("Error recording failed login for user %s: %v", userID, err)
+    } else if int(failures) == cfg.loginPolicy.LockoutAfter {
+        log.Printf("security: user %s locked out after %d failed logins, last from %s", userID, failures, ip)
+    }
+}
+
+func (cfg *apiConfig) resetFailedLogins(ctx context.Context, userID uuid.UUID) {
+    if _, err := cfg.DB.ResetFailedLogins(ctx, userID); err != nil {
+        log.Printf("Error resetting failed logins for user %s: %v", userID, err)
+    }
+}
+
+// issueLoginTokens starts a session for user and responds with its access
+// and refresh tokens.
+func (cfg *apiConfig) issueLoginTokens(w http.ResponseWriter, r *http.Request, user database.User) {
+    refreshToken, err := auth.MakeRefreshToken()
+    if err != nil {
+        http.Error(w, "Failed to create refresh token", http.StatusInternalServerError)
+        return
+    }
+
+    // The session and its first refresh token are created together, so a
+    // failure leaves no session that can never be used.
+    tx, err := cfg.db.BeginTx(r.Context(), nil)
+    if err != nil {
+        http.Error(w, "Failed to create session", http.StatusInternalServerError)
+        return
+    }
+    defer tx.Rollback()
+    qtx := cfg.DB.WithTx(tx)
+
+    session, err := newSession(qtx, r, user.ID)
+    if err != nil {
+        http.Error(w, "Failed to create session", http.StatusInternalServerError)
+        return
+    }
+
+    _, err = qtx.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+        TokenHash: auth.HashRefreshToken(refreshToken),
+        UserID:    user.ID,
+        ExpiresAt: time.Now().Add(cfg.authConfig.RefreshTokenTTL),
+        RevokedAt: sql.NullTime{Valid: false},
+        FamilyID:  session.ID,
+    })
+    if err != nil {
+        http.Error(w, "Failed to create refresh token", http.StatusInternalServerError)
+        return
+    }
+
+    if err := tx.Commit(); err != nil {
+        http.Error(w, "Failed to create session", http.StatusInternalServerError)
+        return
+    }
+
+    token, err := auth.MakeSessionJWT(user.ID, session.ID, user.Role, cfg.jwtKeys, cfg.authConfig.AccessTokenTTL)
+    if err != nil {
+        http.Error(w, "Unknown error", http.StatusInternalServerError)
+        return
+    }
+
+    resultUser := User{
+        ID: user.ID,
+        CreatedAt: user.CreatedAt,
+        UpdatedAt: user.UpdatedAt,
+        Email: user.Email,
+        Token: token,
+        RefreshToken: refreshToken,
+        IsChirpyRed:  user.IsChirpyRed,
+        Role:         user.Role,
+        EmailVerified: user.EmailVerifiedAt.Valid,
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(resultUser)
+}
+
+func (cfg *apiConfig) unlockUserHandler(w http.ResponseWriter, r *http.Request) {
+    userID, err := uuid.Parse(r.PathValue("userID"))
+    if err != nil {
+        http.Error(w, "Invalid user ID", http.StatusBadRequest)
+        return
+    }
+
+    rows, err := cfg.DB.ResetFailedLogins(r.Context(), userID)
+    if err != nil {
+        http.Error(w, "Failed to unlock user", http.StatusInternalServerError)
+        return
+    }
+    if rows == 0 {
+        http.Error(w, "User not found", http.StatusNotFound)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
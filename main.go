@@ -4,14 +4,14 @@ import (
     "context"
     "database/sql"
     "encoding/json"
+    "errors"
     "fmt"
     "log"
     "net/http"
     "os"
-    "strings"
+    "slices"
     "sync/atomic"
     "time"
-    "sort"
 
     _ "github.com/lib/pq"
     "github.com/joho/godotenv"
@@ -19,14 +19,41 @@ import (
 
     "github.com/danon29/chippy/internal/database"
     "github.com/danon29/chippy/internal/auth"
+    "github.com/danon29/chippy/internal/config"
+    "github.com/danon29/chippy/internal/denylist"
+    "github.com/danon29/chippy/internal/mailer"
+    "github.com/danon29/chippy/internal/moderation"
+    "github.com/danon29/chippy/internal/passwordpolicy"
+    "github.com/danon29/chippy/internal/throttle"
+    "github.com/danon29/chippy/internal/totp"
 )
 
 type apiConfig struct {
     fileserverHits atomic.Int32
+    db             *sql.DB
     DB             *database.Queries
     platform       string
-    jwtSecret      string
+    jwtKeys        *auth.Keyring
     polkaKey       string
+    moderation     *moderation.Filter
+    denylist       *denylist.Denylist
+    authConfig     config.Auth
+    passwordParams auth.PasswordParams
+    allowedOrigins []string
+    loginPolicy    throttle.Policy
+    loginThrottle  *throttle.Tracker
+    // unknownEmailThrottle applies loginPolicy to emails without an
+    // account, which have no row to count failures on.
+    unknownEmailThrottle *throttle.Tracker
+    // dummyPasswordHash is compared against when a login names an unknown
+    // email, so that case costs as much as a wrong password.
+    dummyPasswordHash string
+    mailer         mailer.Mailer
+    mailConfig     config.Mail
+    resetEmailThrottle *throttle.Tracker
+    passwordPolicy passwordpolicy.Policy
+    // totpSealer is nil when TOTP_ENCRYPTION_KEY is unset.
+    totpSealer     *totp.Sealer
 }
 
 type User struct {
@@ -37,6 +64,8 @@ type User struct {
     Token     string    `json:"token"`
     RefreshToken string `json:"refresh_token"`
     IsChirpyRed bool `json:"is_chirpy_red"`
+    Role string `json:"role"`
+    EmailVerified bool `json:"email_verified"`
 }
 
 type Chirp struct {
@@ -45,6 +74,143 @@ type Chirp struct {
     UpdatedAt time.Time `json:"updated_at"`
     Body     string    `json:"body"`
     UserId  uuid.UUID `json:"user_id"`
+    ReplyToID *uuid.UUID `json:"reply_to_id,omitempty"`
+    IsRepost bool `json:"is_repost"`
+    RepostOf *EmbeddedChirp `json:"repost_of,omitempty"`
+    LikeCount int64 `json:"like_count"`
+    LikedByMe bool `json:"liked_by_me"`
+
+    repostOfID uuid.NullUUID
+    hidden     bool
+}
+
+type contextKey string
+
+const claimsContextKey contextKey = "claims"
+
+// authenticate validates the request's bearer token. On failure it writes the
+// error response and returns false.
+func (cfg *apiConfig) authenticate(w http.ResponseWriter, r *http.Request) (*auth.Claims, bool) {
+    token, err := auth.GetBearerToken(r.Header)
+    if err != nil {
+        http.Error(w, "No valid token", http.StatusUnauthorized)
+        return nil, false
+    }
+
+    claims, err := cfg.validateAccessToken(token)
+    if err != nil {
+        writeTokenError(w, err)
+        return nil, false
+    }
+
+    return claims, true
+}
+
+// writeTokenError responds 401 with the reason the access token was refused.
+func writeTokenError(w http.ResponseWriter, err error) {
+    reason := "Invalid token"
+    switch {
+    case errors.Is(err, errTokenRevoked):
+        reason = "Token has been revoked"
+    case errors.Is(err, auth.ErrTokenExpired):
+        reason = "Token has expired"
+    case errors.Is(err, auth.ErrTokenNotYetValid):
+        reason = "Token is not valid yet"
+    case errors.Is(err, auth.ErrBadSignature):
+        reason = "Token signature is invalid"
+    case errors.Is(err, auth.ErrWrongIssuer):
+        reason = "Token was issued by someone else"
+    case errors.Is(err, auth.ErrWrongAudience):
+        reason = "Token is not meant for this API"
+    case errors.Is(err, auth.ErrMissingClaim):
+        reason = "Token is missing a required claim"
+    case errors.Is(err, auth.ErrMalformedToken):
+        reason = "Token is malformed"
+    }
+
+    w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="`+reason+`"`)
+    http.Error(w, reason, http.StatusUnauthorized)
+}
+
+// middlewareRequireRole rejects requests whose access token does not carry at
+// least minRole and the admin scope. The claims are stored in the request context.
+func (cfg *apiConfig) middlewareRequireRole(minRole string, next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        claims, ok := cfg.authenticate(w, r)
+        if !ok {
+            return
+        }
+
+        if !auth.HasRole(claims.Role, minRole) || !claims.HasScope(auth.ScopeAdmin) {
+            http.Error(w, "Access denied", http.StatusForbidden)
+            return
+        }
+
+        ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
+
+// middlewareRequireScope rejects requests whose access token was not granted
+// scope. The claims are stored in the request context.
+func (cfg *apiConfig) middlewareRequireScope(scope string, next http.HandlerFunc) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        claims, ok := cfg.authenticate(w, r)
+        if !ok {
+            return
+        }
+
+        if !claims.HasScope(scope) {
+            http.Error(w, "Token lacks the "+scope+" scope", http.StatusForbidden)
+            return
+        }
+
+        ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
+
+// claimsFromContext returns the claims stored by the auth middlewares, or nil.
+func claimsFromContext(ctx context.Context) *auth.Claims {
+    claims, _ := ctx.Value(claimsContextKey).(*auth.Claims)
+    return claims
+}
+
+// userIDFromContext returns the caller's ID stored by the auth middlewares.
+func userIDFromContext(ctx context.Context) uuid.UUID {
+    claims := claimsFromContext(ctx)
+    if claims == nil {
+        return uuid.Nil
+    }
+    userID, _ := uuid.Parse(claims.Subject)
+    return userID
+}
+
+// middlewareCORS lets browsers on the configured origins call the API and
+// answers their preflight requests.
+func (cfg *apiConfig) middlewareCORS(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        origin := r.Header.Get("Origin")
+        if origin == "" || !slices.ContainsFunc(cfg.allowedOrigins, func(allowed string) bool {
+            return allowed == "*" || allowed == origin
+        }) {
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        w.Header().Set("Access-Control-Allow-Origin", origin)
+        w.Header().Add("Vary", "Origin")
+
+        if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+            w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+            w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+            w.Header().Set("Access-Control-Max-Age", "600")
+            w.WriteHeader(http.StatusNoContent)
+            return
+        }
+
+        next.ServeHTTP(w, r)
+    })
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -82,37 +248,134 @@ func (cfg *apiConfig) resetHandler(w http.ResponseWriter, _ *http.Request) {
     fmt.Fprint(w, "✅ All users deleted successfully!")
 }
 
-func censor(body string, profane []string) string {
-    lowered := strings.ToLower(body)
-    result := body
+const maxChirpLength = 140
 
-    for _, bad := range profane {
-        badLower := strings.ToLower(bad)
-
-        for {
-            idx := strings.Index(lowered, badLower)
-            if idx == -1 {
-                break
-            }
+var errChirpTooLong = errors.New("Chirp is too long")
 
-            result = result[:idx] + "****" + result[idx+len(bad):]
-            lowered = lowered[:idx] + "****" + lowered[idx+len(bad):]
-        }
+// validateChirpBody enforces the length limit and returns the censored body.
+func (cfg *apiConfig) validateChirpBody(body string) (string, error) {
+    if len(body) > maxChirpLength {
+        return "", errChirpTooLong
     }
+    return cfg.moderation.Censor(body), nil
+}
+
+// visibleTo reports whether a chirp may be shown to the viewer. Chirps hidden
+// by a moderator stay visible to their author only.
+func visibleTo(chirp database.Chirp, viewerID uuid.UUID) bool {
+    return !chirp.HiddenAt.Valid || chirp.UserID == viewerID
+}
 
+func toChirp(chirp database.Chirp) Chirp {
+    result := Chirp{
+        ID:        chirp.ID,
+        CreatedAt: chirp.CreatedAt,
+        UpdatedAt: chirp.UpdatedAt,
+        Body:      chirp.Body,
+        UserId:    chirp.UserID,
+        hidden:    chirp.HiddenAt.Valid,
+    }
+    if chirp.ReplyToID.Valid {
+        replyToID := chirp.ReplyToID.UUID
+        result.ReplyToID = &replyToID
+    }
+    if chirp.IsRepost {
+        result.IsRepost = true
+        result.repostOfID = chirp.RepostOfID
+    }
     return result
 }
 
+// hydrateChirps fills in the fields of a response batch that need extra
+// queries: like counts and embedded rechirp originals.
+func (cfg *apiConfig) hydrateChirps(ctx context.Context, viewerID uuid.UUID, chirps []Chirp) error {
+    if err := cfg.attachLikes(ctx, viewerID, chirps); err != nil {
+        return err
+    }
+    return cfg.attachReposts(ctx, viewerID, chirps)
+}
+
+// requireUser authenticates the request's bearer token. On failure it writes
+// the error response and returns false.
+func (cfg *apiConfig) requireUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+    if userID := userIDFromContext(r.Context()); userID != uuid.Nil {
+        return userID, true
+    }
+
+    claims, ok := cfg.authenticate(w, r)
+    if !ok {
+        return uuid.Nil, false
+    }
+
+    userID, _ := uuid.Parse(claims.Subject)
+    return userID, true
+}
+
+// optionalUser returns the caller's user ID when the request carries a valid
+// bearer token, and uuid.Nil for anonymous requests.
+func (cfg *apiConfig) optionalUser(r *http.Request) uuid.UUID {
+    token, err := auth.GetBearerToken(r.Header)
+    if err != nil {
+        return uuid.Nil
+    }
+
+    claims, err := cfg.validateAccessToken(token)
+    if err != nil {
+        return uuid.Nil
+    }
+
+    userID, _ := uuid.Parse(claims.Subject)
+    return userID
+}
+
+// ownedChirp loads the chirp named in the path and checks that it belongs to
+// the caller. On failure it writes the error response and returns false.
+func (cfg *apiConfig) ownedChirp(w http.ResponseWriter, r *http.Request) (database.Chirp, bool) {
+    userID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return database.Chirp{}, false
+    }
+
+    chirpIdStr := r.PathValue("chirpId")
+    if chirpIdStr == "" {
+        http.Error(w, "chirpId is required", http.StatusBadRequest)
+        return database.Chirp{}, false
+    }
+
+    chirpID, err := uuid.Parse(chirpIdStr)
+    if err != nil {
+        http.Error(w, "Invalid chirp ID", http.StatusBadRequest)
+        return database.Chirp{}, false
+    }
+
+    chirp, err := cfg.DB.GetChirp(r.Context(), chirpID)
+    if err != nil {
+        http.Error(w, "No such chirp", http.StatusNotFound)
+        return database.Chirp{}, false
+    }
+
+    if chirp.UserID != userID {
+        http.Error(w, "This user does not own the chirp", http.StatusForbidden)
+        return database.Chirp{}, false
+    }
+
+    return chirp, true
+}
+
 func main() {
-    if err := godotenv.Load(); err != nil {
-        log.Fatal("Error loading .env file")
+    // .env is optional; real environment variables take precedence over it.
+    if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+        log.Fatal("Error loading .env file: ", err)
     }
 
-    mux := http.NewServeMux()
+    conf, err := config.Load(os.Getenv)
+    if err != nil {
+        log.Fatalf("Invalid configuration:\n%v", err)
+    }
 
-    dbURL := os.Getenv("DB_URL")
+    mux := http.NewServeMux()
 
-    db, err := sql.Open("postgres", dbURL)
+    db, err := sql.Open("postgres", conf.DatabaseURL)
     if err != nil {
         log.Fatal("Error connecting to DB")
     }
@@ -120,12 +383,77 @@ func main() {
 
     dbQueries := database.New(db)
 
+    var termStore moderation.Store = dbTermStore{q: dbQueries}
+    if conf.ModerationWordsFile != "" {
+        termStore = moderation.NewFileStore(conf.ModerationWordsFile)
+    }
+
+    filter, err := moderation.NewFilter(context.Background(), termStore)
+    if err != nil {
+        log.Fatal("Error loading moderation terms: ", err)
+    }
+
+    tokenDenylist, err := denylist.New(context.Background(), dbDenylistStore{q: dbQueries})
+    if err != nil {
+        log.Fatal("Error loading token denylist: ", err)
+    }
+
+    // Tokens are signed with the asymmetric keys in JWT_KEYS_DIR when it is
+    // set; JWT_SECRET (HS256) remains for local development.
+    jwtKeys := auth.NewHMACKeyring(conf.Auth.JWTSecret)
+    if conf.Auth.JWTKeysDir != "" {
+        jwtKeys, err = auth.LoadKeyring(conf.Auth.JWTKeysDir)
+        if err != nil {
+            log.Fatal("Error loading JWT keys: ", err)
+        }
+    }
+    jwtKeys.Issuer = conf.Auth.Issuer
+
+    dummyPasswordHash, err := auth.HashPassword(uuid.NewString(), auth.PasswordParams(conf.Auth.Argon2))
+    if err != nil {
+        log.Fatal("Error hashing dummy password: ", err)
+    }
+    accountPolicy, ipPolicy := loginPolicies(conf.Auth)
+
+    passwordPolicy, err := newPasswordPolicy(conf.Auth)
+    if err != nil {
+        log.Fatal("Error loading breached password list: ", err)
+    }
+
+    var totpSealer *totp.Sealer
+    if conf.Auth.TOTPEncryptionKey != "" {
+        totpKey, err := conf.Auth.TOTPKey()
+        if err != nil {
+            log.Fatal("Error loading TOTP encryption key: ", err)
+        }
+        totpSealer, err = totp.NewSealer(totpKey)
+        if err != nil {
+            log.Fatal("Error loading TOTP encryption key: ", err)
+        }
+    }
+
     cfg := apiConfig{
+        db:       db,
         DB:       dbQueries,
-        platform: os.Getenv("PLATFORM"),
-        jwtSecret: os.Getenv("JWT_SECRET"),
-        polkaKey: os.Getenv("POLKA_KEY"),
+        platform: conf.Platform,
+        jwtKeys:  jwtKeys,
+        polkaKey: conf.PolkaKey,
+        moderation: filter,
+        denylist: tokenDenylist,
+        authConfig: conf.Auth,
+        passwordParams: auth.PasswordParams(conf.Auth.Argon2),
+        allowedOrigins: conf.AllowedOrigins,
+        loginPolicy:    accountPolicy,
+        loginThrottle:  throttle.NewTracker(ipPolicy),
+        unknownEmailThrottle: throttle.NewTracker(accountPolicy),
+        dummyPasswordHash: dummyPasswordHash,
+        totpSealer:     totpSealer,
+        mailer:         newMailer(conf.Mail),
+        mailConfig:     conf.Mail,
+        resetEmailThrottle: throttle.NewTracker(passwordResetEmailPolicy),
+        passwordPolicy: passwordPolicy,
     }
+    go cfg.reloadDenylist(context.Background())
 
     customHandler := func(w http.ResponseWriter, r *http.Request) {
         w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -143,35 +471,87 @@ func main() {
         ),
     )
 
-    // Admin
-    mux.HandleFunc("GET /admin/metrics", cfg.hitHandler)
-	mux.HandleFunc("POST /admin/reset", cfg.resetHandler)
+    // Admin: every /admin route needs at least a moderator token, and the
+    // routes below that change the whole site need an admin one. The
+    // moderator check has already authenticated the request, so adminOnly
+    // only looks at the role it stored.
+    adminOnly := func(h http.HandlerFunc) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            claims := claimsFromContext(r.Context())
+            if claims == nil || !auth.HasRole(claims.Role, auth.RoleAdmin) {
+                http.Error(w, "Access denied", http.StatusForbidden)
+                return
+            }
+            h(w, r)
+        })
+    }
+
+    adminMux := http.NewServeMux()
+    adminMux.Handle("GET /admin/metrics", adminOnly(cfg.hitHandler))
+	adminMux.Handle("POST /admin/reset", adminOnly(cfg.resetHandler))
+    adminMux.Handle("PUT /admin/users/{userID}/role", adminOnly(cfg.setUserRoleHandler))
+    adminMux.Handle("POST /admin/users/{userID}/ban", adminOnly(cfg.banUserHandler))
+    adminMux.Handle("DELETE /admin/users/{userID}/ban", adminOnly(cfg.unbanUserHandler))
+    adminMux.Handle("POST /admin/users/{userID}/unlock", adminOnly(cfg.unlockUserHandler))
+    adminMux.Handle("GET /admin/moderation/terms", adminOnly(cfg.listTermsHandler))
+    adminMux.Handle("POST /admin/moderation/terms", adminOnly(cfg.addTermHandler))
+    adminMux.Handle("DELETE /admin/moderation/terms/{term}", adminOnly(cfg.removeTermHandler))
+    adminMux.HandleFunc("GET /admin/reports", cfg.listReportsHandler)
+    adminMux.HandleFunc("POST /admin/reports/{reportID}/resolve", cfg.resolveReportHandler)
+    mux.Handle("/admin/", cfg.middlewareRequireRole(auth.RoleModerator, adminMux))
 
 
     // Test handler
     mux.HandleFunc("GET /api/healthz", customHandler)
+    mux.HandleFunc("GET /.well-known/jwks.json", cfg.jwksHandler)
 
 
     // Chirps
     mux.HandleFunc("GET /api/chirps", func(w http.ResponseWriter, r *http.Request) {
-
         authorIDStr := r.URL.Query().Get("author_id")
 
         sortType := r.URL.Query().Get("sort")
 
-        var chirps []database.Chirp
-        var err error
+        page, err := parsePageParams(r.URL.Query())
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
 
+        var authorID uuid.NullUUID
         if authorIDStr != "" {
-            authorID, parseErr := uuid.Parse(authorIDStr)
+            id, parseErr := uuid.Parse(authorIDStr)
             if parseErr != nil {
                 http.Error(w, "Invalid author ID format", http.StatusBadRequest)
                 return
             }
-            
-            chirps, err = cfg.DB.GetChirpByUserId(r.Context(), authorID)
+            authorID = uuid.NullUUID{UUID: id, Valid: true}
+        }
+
+        cursorCreatedAt, cursorID := page.cursorArgs()
+
+        // Hidden chirps are only listed for their author.
+        viewerID := cfg.optionalUser(r)
+        viewer := uuid.NullUUID{UUID: viewerID, Valid: viewerID != uuid.Nil}
+
+        // Fetch one extra row to find out whether there is a next page.
+        var chirps []database.Chirp
+        if sortType == "desc" {
+            chirps, err = cfg.DB.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+                AuthorID:        authorID,
+                CursorCreatedAt: cursorCreatedAt,
+                CursorID:        cursorID,
+                ViewerID:        viewer,
+                PageSize:        page.Limit + 1,
+            })
         } else {
-            chirps, err = cfg.DB.GetChirps(r.Context())
+            chirps, err = cfg.DB.ListChirpsAsc(r.Context(), database.ListChirpsAscParams{
+                AuthorID:        authorID,
+                CursorCreatedAt: cursorCreatedAt,
+                CursorID:        cursorID,
+                ViewerID:        viewer,
+                PageSize:        page.Limit + 1,
+            })
         }
 
         if err != nil {
@@ -179,32 +559,17 @@ func main() {
             return
         }
 
-        resp := make([]Chirp, 0, len(chirps))
-
-        for _, chirp := range chirps {
-            resp = append(resp, Chirp{
-                ID: chirp.ID,
-                CreatedAt: chirp.CreatedAt,
-                UpdatedAt: chirp.CreatedAt,
-                Body: chirp.Body,
-                UserId: chirp.UserID,
-            })
-        }
-
-        if sortType == "desc" {
-            sort.Slice(resp, func(i, j int) bool { 
-                return resp[i].CreatedAt.After(resp[j].CreatedAt)  
-            })
-        } else { 
-            sort.Slice(resp, func(i, j int) bool { 
-                return resp[i].CreatedAt.Before(resp[j].CreatedAt)
-            })
+        resp := newChirpPage(chirps, page.Limit)
+        if err := cfg.hydrateChirps(r.Context(), viewerID, resp.Chirps); err != nil {
+            http.Error(w, "Error", http.StatusInternalServerError)
+            return
         }
-        
 
+        w.Header().Set("Content-Type", "application/json")
         w.WriteHeader(http.StatusOK)
         json.NewEncoder(w).Encode(resp)
     })
+    mux.HandleFunc("GET /api/chirps/search", cfg.searchChirpsHandler)
     mux.HandleFunc("GET /api/chirps/{chirpId}", func(w http.ResponseWriter, r *http.Request) {
         chirpIdStr := r.PathValue("chirpId")
         if chirpIdStr == "" {
@@ -223,70 +588,50 @@ func main() {
             http.Error(w, "Name parameter is required", http.StatusNotFound)
 		    return 
         }
-        
-
-        result := Chirp{
-                ID: chirp.ID,
-                CreatedAt: chirp.CreatedAt,
-                UpdatedAt: chirp.CreatedAt,
-                Body: chirp.Body,
-                UserId: chirp.UserID,
-            }
-        w.WriteHeader(http.StatusOK)
-        json.NewEncoder(w).Encode(result)
-    })
-    mux.HandleFunc("DELETE /api/chirps/{chirpId}", func(w http.ResponseWriter, r *http.Request) {
-        token, err := auth.GetBearerToken(r.Header)
-        if err != nil {
-            http.Error(w, "No valid token", http.StatusUnauthorized)
-            return 
-        }
 
-        userID, err := auth.ValidateJWT(token, cfg.jwtSecret) 
-        if err != nil || userID == uuid.Nil {
-            http.Error(w, "Invalid token", http.StatusUnauthorized)
-            return
-        }
-
-        chirpIdStr := r.PathValue("chirpId")
-        if chirpIdStr == "" {
-            http.Error(w, "chirpId is required", http.StatusBadRequest)
-            return
-        }
-
-        chirpID, err := uuid.Parse(chirpIdStr) 
-        if err != nil {
-            http.Error(w, "Invalid chirp ID", http.StatusBadRequest)
+        viewerID := cfg.optionalUser(r)
+        if !visibleTo(chirp, viewerID) {
+            http.Error(w, "No such chirp", http.StatusNotFound)
             return
         }
 
-        chirp, err := cfg.DB.GetChirp(r.Context(), chirpID)
-        if err != nil {
-            http.Error(w, "No such chirp", http.StatusNotFound)
+        result := []Chirp{toChirp(chirp)}
+        if err := cfg.hydrateChirps(r.Context(), viewerID, result); err != nil {
+            http.Error(w, "Error", http.StatusInternalServerError)
             return
         }
 
-        if chirp.UserID != userID {
-            http.Error(w, "This user cannot delete the chirp", http.StatusForbidden)
+        w.WriteHeader(http.StatusOK)
+        json.NewEncoder(w).Encode(result[0])
+    })
+    mux.Handle("DELETE /api/chirps/{chirpId}", cfg.middlewareRequireScope(auth.ScopeChirpsWrite, func(w http.ResponseWriter, r *http.Request) {
+        chirp, ok := cfg.ownedChirp(w, r)
+        if !ok {
             return
         }
 
-
-        err = cfg.DB.DeleteChirp(r.Context(), chirpID)
+        err := cfg.DB.DeleteChirp(r.Context(), chirp.ID)
         if err != nil {
             http.Error(w, "Failed to delete chirp", http.StatusNotFound)
             return
         }
 
          w.WriteHeader(http.StatusNoContent)
-    })
-    mux.HandleFunc("POST /api/chirps", func(w http.ResponseWriter, r *http.Request) {
+    }))
+    mux.Handle("PUT /api/chirps/{chirpId}", cfg.middlewareRequireScope(auth.ScopeChirpsWrite, cfg.updateChirpHandler))
+    mux.HandleFunc("GET /api/chirps/{chirpId}/revisions", cfg.chirpRevisionsHandler)
+    mux.HandleFunc("GET /api/chirps/{chirpId}/replies", cfg.chirpRepliesHandler)
+    mux.HandleFunc("GET /api/chirps/{chirpId}/thread", cfg.chirpThreadHandler)
+    mux.Handle("POST /api/chirps/{chirpId}/rechirps", cfg.middlewareRequireScope(auth.ScopeChirpsWrite, cfg.rechirpHandler))
+    mux.Handle("POST /api/chirps/{chirpId}/reports", cfg.middlewareRequireScope(auth.ScopeChirpsWrite, cfg.reportChirpHandler))
+    mux.Handle("POST /api/chirps/{chirpId}/likes", cfg.middlewareRequireScope(auth.ScopeChirpsWrite, cfg.likeChirpHandler))
+    mux.Handle("DELETE /api/chirps/{chirpId}/likes", cfg.middlewareRequireScope(auth.ScopeChirpsWrite, cfg.unlikeChirpHandler))
+    mux.Handle("POST /api/chirps", cfg.middlewareRequireScope(auth.ScopeChirpsWrite, func(w http.ResponseWriter, r *http.Request) {
         type params struct {
             Body string `json:"body"`
+            ReplyToID *uuid.UUID `json:"reply_to_id"`
         }
 
-        profaneWords := []string{"kerfuffle", "sharbert", "fornax"}
-
         type errorResponse struct {
             Error string `json:"error"`
         }
@@ -296,40 +641,51 @@ func main() {
             UserId uuid.UUID `json:"user_id"`
         }
 
-        token, err := auth.GetBearerToken(r.Header)
-        if err != nil {
-            http.Error(w, "No valid tokens", http.StatusUnauthorized)
-            return
-        }
-
-        userID, err := auth.ValidateJWT(token, cfg.jwtSecret) 
-        if err != nil || userID == uuid.Nil {
-            http.Error(w, "Invalid token", http.StatusUnauthorized)
-            return
-        }
+        userID := userIDFromContext(r.Context())
 
         w.Header().Set("Content-Type", "application/json")
 
         var p params
         decoder := json.NewDecoder(r.Body)
-        err = decoder.Decode(&p)
+        err := decoder.Decode(&p)
         if err != nil {
             w.WriteHeader(http.StatusBadRequest)
             json.NewEncoder(w).Encode(errorResponse{Error: "Something went wrong"})
             return
         }
 
-        if len(p.Body) > 140 {
+        cleaned, err := cfg.validateChirpBody(p.Body)
+        if err != nil {
             w.WriteHeader(http.StatusBadRequest)
-            json.NewEncoder(w).Encode(errorResponse{Error: "Chirp is too long"})
+            json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
             return
         }
 
-        cleaned := censor(p.Body, profaneWords)
+        var replyToID uuid.NullUUID
+        if p.ReplyToID != nil {
+            parent, err := cfg.DB.GetChirp(r.Context(), *p.ReplyToID)
+            if err != nil || !visibleTo(parent, userID) {
+                w.WriteHeader(http.StatusNotFound)
+                json.NewEncoder(w).Encode(errorResponse{Error: "Parent chirp not found"})
+                return
+            }
+            replyToID = uuid.NullUUID{UUID: *p.ReplyToID, Valid: true}
+        }
 
-        chirp, err := cfg.DB.CreateChirp(r.Context(), database.CreateChirpParams{
+        tx, err := cfg.db.BeginTx(r.Context(), nil)
+        if err != nil {
+            w.WriteHeader(http.StatusInternalServerError)
+            json.NewEncoder(w).Encode(errorResponse{Error: "Error while creating chirp"})
+            return
+        }
+        defer tx.Rollback()
+
+        qtx := cfg.DB.WithTx(tx)
+
+        chirp, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
             Body: cleaned,
             UserID: userID,
+            ReplyToID: replyToID,
         })
 
         if err != nil {
@@ -337,15 +693,21 @@ func main() {
             json.NewEncoder(w).Encode(errorResponse{Error: "Error while creating chirp"})
             return
         }
+
+        if err := indexChirpEntities(r.Context(), qtx, chirp); err != nil {
+            w.WriteHeader(http.StatusInternalServerError)
+            json.NewEncoder(w).Encode(errorResponse{Error: "Error while creating chirp"})
+            return
+        }
+
+        if err := tx.Commit(); err != nil {
+            w.WriteHeader(http.StatusInternalServerError)
+            json.NewEncoder(w).Encode(errorResponse{Error: "Error while creating chirp"})
+            return
+        }
         w.WriteHeader(http.StatusCreated)
-        json.NewEncoder(w).Encode(Chirp{
-                ID: chirp.ID,
-                CreatedAt: chirp.CreatedAt,
-                UpdatedAt: chirp.CreatedAt,
-                Body: chirp.Body,
-                UserId: chirp.UserID,
-            })
-    })
+        json.NewEncoder(w).Encode(toChirp(chirp))
+    }))
     
 
     // Users
@@ -363,7 +725,15 @@ func main() {
             return
         }
 
-        hashedPassword, err := auth.HashPassword(p.Password)
+        if err := mailer.ValidateAddress(p.Email); err != nil {
+            http.Error(w, "Invalid email address", http.StatusBadRequest)
+            return
+        }
+        if !cfg.checkPassword(r.Context(), w, p.Password, p.Email) {
+            return
+        }
+
+        hashedPassword, err := auth.HashPassword(p.Password, cfg.passwordParams)
         if err != nil {
             http.Error(w, "Failed to create user", http.StatusInternalServerError)
             return
@@ -376,6 +746,7 @@ func main() {
             http.Error(w, "Failed to create user", http.StatusInternalServerError)
             return
         }
+        cfg.sendVerificationEmailAsync(user.ID, user.Email)
 
 		resultUser := User{
 			ID: user.ID,
@@ -383,23 +754,15 @@ func main() {
 			UpdatedAt: user.UpdatedAt,
 			Email: user.Email,
             IsChirpyRed: user.IsChirpyRed,
+            Role: user.Role,
+            EmailVerified: user.EmailVerifiedAt.Valid,
 		}
 
         w.WriteHeader(http.StatusCreated)
         json.NewEncoder(w).Encode(resultUser)
     })
-    mux.HandleFunc("PUT /api/users", func(w http.ResponseWriter, r *http.Request) {
-        token, err := auth.GetBearerToken(r.Header)
-        if err != nil {
-            http.Error(w, "No valid tokens", http.StatusUnauthorized)
-            return
-        }
-
-        userID, err := auth.ValidateJWT(token, cfg.jwtSecret) 
-        if err != nil || userID == uuid.Nil {
-            http.Error(w, "Invalid token", http.StatusUnauthorized)
-            return
-        }
+    mux.Handle("PUT /api/users", cfg.middlewareRequireScope(auth.ScopeUsersWrite, func(w http.ResponseWriter, r *http.Request) {
+        userID := userIDFromContext(r.Context())
 
         type params struct {
             Email string `json:"email"`
@@ -412,7 +775,28 @@ func main() {
             return
         }
 
-        hashedPassword, err := auth.HashPassword(p.Password)
+        if err := mailer.ValidateAddress(p.Email); err != nil {
+            http.Error(w, "Invalid email address", http.StatusBadRequest)
+            return
+        }
+
+        currentUser, err := cfg.DB.GetUserByID(r.Context(), userID)
+        if err != nil {
+            http.Error(w, "Failed to update user", http.StatusInternalServerError)
+            return
+        }
+
+        samePassword, err := auth.CheckPasswordHash(p.Password, currentUser.HashedPassword)
+        if err != nil {
+            http.Error(w, "Failed to update user", http.StatusInternalServerError)
+            return
+        }
+        // Passwords set before the policy existed stay usable until changed.
+        if !samePassword && !cfg.checkPassword(r.Context(), w, p.Password, p.Email) {
+            return
+        }
+
+        hashedPassword, err := auth.HashPassword(p.Password, cfg.passwordParams)
         if err != nil {
             http.Error(w, "Failed to hash password", http.StatusInternalServerError)
             return
@@ -428,6 +812,19 @@ func main() {
             return
         }
 
+        // A new address has to be verified again.
+        if updatedUser.Email != currentUser.Email {
+            cfg.sendVerificationEmailAsync(updatedUser.ID, updatedUser.Email)
+        }
+
+        // A new password ends every existing session, including this one.
+        if !samePassword {
+            if err := cfg.revokeAllUserTokens(r.Context(), userID); err != nil {
+                http.Error(w, "Failed to revoke tokens", http.StatusInternalServerError)
+                return
+            }
+        }
+
         w.Header().Set("Content-Type", "application/json")
         w.WriteHeader(http.StatusOK)
         json.NewEncoder(w).Encode(User{
@@ -436,134 +833,84 @@ func main() {
             UpdatedAt: updatedUser.UpdatedAt,
             Email:     updatedUser.Email,
             IsChirpyRed: updatedUser.IsChirpyRed,
+            Role:      updatedUser.Role,
+            EmailVerified: updatedUser.EmailVerifiedAt.Valid,
         })
-    })
-
-
-    // Auth
-    mux.HandleFunc("POST /api/login", func(w http.ResponseWriter, r *http.Request) {
-        type params struct {
-            Password string `json:"password"`
-            Email string `json:"email"`
-        }
+    }))
 
-        var p params
-        if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-            http.Error(w, "Invalid request", http.StatusBadRequest)
-            return
-        }
 
-        user, err := cfg.DB.FindUser(r.Context(), p.Email)
-       
+    // Follows
+    mux.Handle("POST /api/users/{userID}/follow", cfg.middlewareRequireScope(auth.ScopeUsersWrite, cfg.followHandler))
+    mux.Handle("DELETE /api/users/{userID}/follow", cfg.middlewareRequireScope(auth.ScopeUsersWrite, cfg.unfollowHandler))
+    mux.HandleFunc("GET /api/users/{userID}/followers", cfg.followersHandler)
+    mux.HandleFunc("GET /api/users/{userID}/following", cfg.followingHandler)
+    mux.HandleFunc("GET /api/timeline", cfg.timelineHandler)
 
-        isValidPassword, err := auth.CheckPasswordHash(p.Password, user.HashedPassword)
-        if err != nil {
-            http.Error(w, "Unknown error", http.StatusInternalServerError)
-            return
-        }
 
-        if err != nil || !isValidPassword {
-            w.Header().Set("Content-Type", "application/json")
-            w.WriteHeader(http.StatusUnauthorized)
-            json.NewEncoder(w).Encode(struct{ Error string }{"Incorrect email or password"})
-            return
-        }
+    // Discovery
+    mux.HandleFunc("GET /api/hashtags/trending", cfg.trendingHashtagsHandler)
+    mux.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.hashtagChirpsHandler)
+    mux.HandleFunc("GET /api/users/me/mentions", cfg.mentionsHandler)
 
-        jwtExpiresTime := 1 * time.Hour
-        refreshExpiresTime := 60 * 24 * time.Hour
-       
-        token, err := auth.MakeJWT(user.ID, cfg.jwtSecret, jwtExpiresTime)
-        if err != nil {
-            http.Error(w, "Unknown error", http.StatusInternalServerError)
-            return
-        }
-
-        refreshToken, err := auth.MakeRefreshToken()
-        if err != nil {
-            http.Error(w, "Failed to create refresh token", http.StatusInternalServerError)
-            return
-        }
 
-        _, err = cfg.DB.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-            Token:     refreshToken,                   
-            UserID:    user.ID,
-            ExpiresAt: time.Now().Add(refreshExpiresTime), 
-            RevokedAt: sql.NullTime{Valid: false},
-        })
-        if err != nil {
-            http.Error(w, "Failed to create refresh token", http.StatusInternalServerError)
-            return
-        }
-
-        resultUser := User{
-			ID: user.ID,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-			Email: user.Email,
-            Token: token,
-            RefreshToken: refreshToken,
-            IsChirpyRed:  user.IsChirpyRed,
-		}
-
-        w.WriteHeader(http.StatusOK)
-        json.NewEncoder(w).Encode(resultUser)
-    })
-    mux.HandleFunc("POST /api/refresh", func(w http.ResponseWriter, r *http.Request) {
-        refreshTokenStr, err := auth.GetBearerToken(r.Header)
+    // Auth
+    mux.HandleFunc("POST /api/login", cfg.loginHandler)
+    mux.HandleFunc("POST /api/users/verify", cfg.verifyEmailHandler)
+    mux.Handle("POST /api/users/me/2fa/setup", cfg.middlewareRequireScope(auth.ScopeUsersWrite, cfg.setupTwoFactorHandler))
+    mux.Handle("POST /api/users/me/2fa/confirm", cfg.middlewareRequireScope(auth.ScopeUsersWrite, cfg.confirmTwoFactorHandler))
+    mux.HandleFunc("POST /api/password/forgot", cfg.forgotPasswordHandler)
+    mux.HandleFunc("POST /api/password/reset", cfg.resetPasswordHandler)
+    mux.HandleFunc("POST /api/refresh", cfg.refreshHandler)
+    mux.HandleFunc("POST /api/revoke", func(w http.ResponseWriter, r *http.Request) {
+        token, err := auth.GetBearerToken(r.Header)
         if err != nil {
             http.Error(w, "No valid tokens", http.StatusUnauthorized)
             return
         }
 
-        refreshToken, err := cfg.DB.GetRefreshToken(r.Context(), refreshTokenStr)
+        tokenHash := auth.HashRefreshToken(token)
+        revoked, err := cfg.DB.RevokeRefreshToken(r.Context(), tokenHash)
         if err != nil {
-            http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+            http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
             return
         }
-
-        if refreshToken.RevokedAt.Valid {
-            http.Error(w, "Refresh token revoked", http.StatusUnauthorized)
-            return
-        }
-
-        if time.Now().After(refreshToken.ExpiresAt) {
-            http.Error(w, "Refresh token expired", http.StatusUnauthorized)
+        if revoked == 0 {
+            http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
             return
         }
 
-        jwtExpiresTime := 1 * time.Hour
-        newAccessToken, err := auth.MakeJWT(refreshToken.UserID, cfg.jwtSecret, jwtExpiresTime)
+        // Logging out ends the session, so its access tokens stop working
+        // now rather than when they expire.
+        refreshToken, err := cfg.DB.GetRefreshToken(r.Context(), tokenHash)
         if err != nil {
-            http.Error(w, "Failed to generate access token", http.StatusInternalServerError)
+            http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
             return
         }
-
-        err = cfg.DB.UpdateRefreshToken(r.Context(), refreshTokenStr)
-        if err != nil {
-            http.Error(w, "Failed to update token", http.StatusInternalServerError)
+        if err := cfg.denylist.RevokeSession(r.Context(), refreshToken.FamilyID, cfg.maxAccessTokenTTL()); err != nil {
+            http.Error(w, "Failed to revoke access token", http.StatusInternalServerError)
             return
         }
 
-        w.Header().Set("Content-Type", "application/json")
-        json.NewEncoder(w).Encode(map[string]string{
-            "token": newAccessToken,  
-        })
-    })
-    mux.HandleFunc("POST /api/revoke", func(w http.ResponseWriter, r *http.Request) {
-        token, err := auth.GetBearerToken(r.Header)
-        if err != nil {
-            http.Error(w, "No valid tokens", http.StatusUnauthorized)
-            return
+        // Clients may also hand over an access token from before tokens
+        // carried their session.
+        var p struct {
+            AccessToken string `json:"access_token"`
         }
-
-        err = cfg.DB.RevokeRefreshToken(r.Context(), token)
-        if err != nil {
-            http.Error(w, "Error", http.StatusNotFound)
-            return
+        if err := json.NewDecoder(r.Body).Decode(&p); err == nil && p.AccessToken != "" {
+            if claims, err := auth.ValidateJWTWithClaims(p.AccessToken, cfg.jwtKeys); err == nil {
+                if err := cfg.denylist.RevokeToken(r.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+                    http.Error(w, "Failed to revoke access token", http.StatusInternalServerError)
+                    return
+                }
+            }
         }
 
         w.WriteHeader(http.StatusNoContent)
     })
+    mux.HandleFunc("GET /api/sessions", cfg.listSessionsHandler)
+    mux.Handle("DELETE /api/sessions/{sessionID}", cfg.middlewareRequireScope(auth.ScopeUsersWrite, cfg.revokeSessionHandler))
+    mux.Handle("DELETE /api/sessions", cfg.middlewareRequireScope(auth.ScopeUsersWrite, cfg.revokeAllSessionsHandler))
+    mux.Handle("POST /api/tokens", cfg.middlewareRequireScope(auth.ScopeUsersWrite, cfg.createScopedTokenHandler))
 
     mux.HandleFunc("POST /api/polka/webhooks", func(w http.ResponseWriter, r *http.Request) {
         type params struct {
@@ -615,6 +962,6 @@ func main() {
         w.WriteHeader(http.StatusNoContent)
     })
 
-    server := http.Server{Addr: ":8080", Handler: mux}
+    server := http.Server{Addr: ":8080", Handler: cfg.middlewareCORS(mux)}
     log.Fatal(server.ListenAndServe())
 }
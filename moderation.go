@@ -0,0 +1,74 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+
+    "github.com/danon29/chippy/internal/database"
+    "github.com/danon29/chippy/internal/moderation"
+)
+
+// dbTermStore keeps the moderation term list in the banned_terms table.
+type dbTermStore struct {
+    q *database.Queries
+}
+
+func (s dbTermStore) ListTerms(ctx context.Context) ([]string, error) {
+    return s.q.ListBannedTerms(ctx)
+}
+
+func (s dbTermStore) AddTerm(ctx context.Context, term string) error {
+    return s.q.AddBannedTerm(ctx, term)
+}
+
+func (s dbTermStore) RemoveTerm(ctx context.Context, term string) error {
+    return s.q.RemoveBannedTerm(ctx, term)
+}
+
+func (cfg *apiConfig) listTermsHandler(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(cfg.moderation.Terms())
+}
+
+func (cfg *apiConfig) addTermHandler(w http.ResponseWriter, r *http.Request) {
+    type params struct {
+        Term string `json:"term"`
+    }
+
+    var p params
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, "Invalid request", http.StatusBadRequest)
+        return
+    }
+
+    term, err := cfg.moderation.Add(r.Context(), p.Term)
+    if err != nil {
+        if errors.Is(err, moderation.ErrInvalidTerm) {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        http.Error(w, "Failed to add term", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(map[string]string{"term": term})
+}
+
+func (cfg *apiConfig) removeTermHandler(w http.ResponseWriter, r *http.Request) {
+    err := cfg.moderation.Remove(r.Context(), r.PathValue("term"))
+    if err != nil {
+        if errors.Is(err, moderation.ErrInvalidTerm) {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        http.Error(w, "Failed to remove term", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
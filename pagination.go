@@ -0,0 +1,120 @@
+package main
+
+import (
+    "database/sql"
+    "encoding/base64"
+    "errors"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/database"
+)
+
+const (
+    defaultPageSize = 20
+    maxPageSize     = 100
+)
+
+// cursor is the position of the last item on a page. Pages are ordered by
+// (created_at, id) so the id breaks ties between chirps created in the same instant.
+type cursor struct {
+    CreatedAt time.Time
+    ID        uuid.UUID
+}
+
+func encodeCursor(c cursor) string {
+    raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+    return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (cursor, error) {
+    raw, err := base64.RawURLEncoding.DecodeString(s)
+    if err != nil {
+        return cursor{}, errors.New("invalid cursor")
+    }
+
+    parts := strings.SplitN(string(raw), "|", 2)
+    if len(parts) != 2 {
+        return cursor{}, errors.New("invalid cursor")
+    }
+
+    createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+    if err != nil {
+        return cursor{}, errors.New("invalid cursor")
+    }
+
+    id, err := uuid.Parse(parts[1])
+    if err != nil {
+        return cursor{}, errors.New("invalid cursor")
+    }
+
+    return cursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+type pageParams struct {
+    Limit  int32
+    Cursor *cursor
+}
+
+// parsePageParams reads the limit and cursor query parameters.
+func parsePageParams(q url.Values) (pageParams, error) {
+    p := pageParams{Limit: defaultPageSize}
+
+    if limitStr := q.Get("limit"); limitStr != "" {
+        limit, err := strconv.Atoi(limitStr)
+        if err != nil || limit < 1 {
+            return p, errors.New("invalid limit")
+        }
+        if limit > maxPageSize {
+            limit = maxPageSize
+        }
+        p.Limit = int32(limit)
+    }
+
+    if cursorStr := q.Get("cursor"); cursorStr != "" {
+        c, err := decodeCursor(cursorStr)
+        if err != nil {
+            return p, err
+        }
+        p.Cursor = &c
+    }
+
+    return p, nil
+}
+
+// cursorArgs converts the cursor into the nullable keyset arguments taken by
+// the paginated queries. Both are NULL on the first page.
+func (p pageParams) cursorArgs() (sql.NullTime, uuid.NullUUID) {
+    if p.Cursor == nil {
+        return sql.NullTime{}, uuid.NullUUID{}
+    }
+    return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true},
+        uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+type ChirpPage struct {
+    Chirps     []Chirp `json:"chirps"`
+    NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// newChirpPage builds a response page from rows fetched with a page size of
+// limit+1; the extra row only signals that another page exists.
+func newChirpPage(chirps []database.Chirp, limit int32) ChirpPage {
+    page := ChirpPage{Chirps: make([]Chirp, 0, len(chirps))}
+
+    if len(chirps) > int(limit) {
+        chirps = chirps[:limit]
+        last := chirps[len(chirps)-1]
+        page.NextCursor = encodeCursor(cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+    }
+
+    for _, chirp := range chirps {
+        page.Chirps = append(page.Chirps, toChirp(chirp))
+    }
+
+    return page
+}
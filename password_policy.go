@@ -0,0 +1,53 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "log"
+    "net/http"
+
+    "github.com/danon29/chippy/internal/config"
+    "github.com/danon29/chippy/internal/passwordpolicy"
+)
+
+// newPasswordPolicy builds the policy from conf, loading the breached
+// password list if one is configured.
+func newPasswordPolicy(conf config.Auth) (passwordpolicy.Policy, error) {
+    policy := passwordpolicy.Policy{
+        MinLength: conf.PasswordMinLength,
+        MaxLength: conf.PasswordMaxLength,
+    }
+    if conf.BreachedPasswordsFile != "" {
+        list, err := passwordpolicy.LoadBreachedList(conf.BreachedPasswordsFile)
+        if err != nil {
+            return policy, err
+        }
+        policy.Breached = list
+    }
+    return policy, nil
+}
+
+// checkPassword applies the password policy. When the password is rejected
+// it writes a 400 listing every violation and returns false.
+func (cfg *apiConfig) checkPassword(ctx context.Context, w http.ResponseWriter, password, email string) bool {
+    err := cfg.passwordPolicy.Check(ctx, password, email)
+    if err == nil {
+        return true
+    }
+
+    var policyErr *passwordpolicy.Error
+    if !errors.As(err, &policyErr) {
+        log.Printf("Error checking password policy: %v", err)
+        http.Error(w, "Failed to check password", http.StatusInternalServerError)
+        return false
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusBadRequest)
+    json.NewEncoder(w).Encode(struct {
+        Error      string                     `json:"error"`
+        Violations []passwordpolicy.Violation `json:"violations"`
+    }{"Password does not meet the requirements", policyErr.Violations})
+    return false
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/danon29/chippy/internal/auth"
+    "github.com/danon29/chippy/internal/database"
+    "github.com/danon29/chippy/internal/mailer"
+    "github.com/danon29/chippy/internal/throttle"
+)
+
+// passwordResetEmailPolicy limits how often reset emails go to one address,
+// so the endpoint cannot be used to flood someone's inbox.
+var passwordResetEmailPolicy = throttle.Policy{
+    FreeAttempts:    2,
+    BaseDelay:       time.Minute,
+    MaxDelay:        15 * time.Minute,
+    LockoutAfter:    5,
+    LockoutDuration: time.Hour,
+    ResetAfter:      time.Hour,
+}
+
+// forgotPasswordHandler always answers 202 so it does not reveal which
+// emails have accounts. The lookup and the email happen in the background
+// for the same reason.
+func (cfg *apiConfig) forgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+    type params struct {
+        Email string `json:"email"`
+    }
+
+    var p params
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, "Invalid request", http.StatusBadRequest)
+        return
+    }
+
+    key := strings.ToLower(p.Email)
+    if mailer.ValidateAddress(p.Email) == nil && cfg.resetEmailThrottle.RetryAfter(key) == 0 {
+        cfg.resetEmailThrottle.Fail(key)
+        go func() {
+            ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+            defer cancel()
+            if err := cfg.sendPasswordResetEmail(ctx, p.Email); err != nil {
+                log.Printf("Error sending password reset email: %v", err)
+            }
+        }()
+    }
+
+    w.WriteHeader(http.StatusAccepted)
+}
+
+// sendPasswordResetEmail mails a one-time reset link to the account with
+// email, if there is one. A new link replaces any earlier ones.
+func (cfg *apiConfig) sendPasswordResetEmail(ctx context.Context, email string) error {
+    user, err := cfg.DB.FindUser(ctx, email)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    if user.BannedAt.Valid {
+        return nil
+    }
+
+    // Reset tokens are random like refresh tokens and stored the same way,
+    // as SHA-256 digests.
+    token, err := auth.MakeRefreshToken()
+    if err != nil {
+        return err
+    }
+
+    if err := cfg.DB.DeletePasswordResetTokens(ctx, user.ID); err != nil {
+        return err
+    }
+    err = cfg.DB.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+        TokenHash: auth.HashRefreshToken(token),
+        UserID:    user.ID,
+        ExpiresAt: time.Now().Add(cfg.authConfig.PasswordResetTTL),
+    })
+    if err != nil {
+        return err
+    }
+
+    link, err := linkWithToken(cfg.mailConfig.ResetURL, token)
+    if err != nil {
+        return err
+    }
+
+    return cfg.mailer.Send(ctx, mailer.Message{
+        To:      user.Email,
+        Subject: "Reset your Chirpy password",
+        Body: fmt.Sprintf("Someone asked to reset the password of your Chirpy account. To choose a new one, open the link below.\n\n%s\n\n"+
+            "The link expires in %s and works once. If you did not ask for this, ignore this email; your password is unchanged.\n",
+            link, cfg.authConfig.PasswordResetTTL),
+    })
+}
+
+func (cfg *apiConfig) resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+    type params struct {
+        Token    string `json:"token"`
+        Password string `json:"password"`
+    }
+
+    var p params
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, "Invalid request", http.StatusBadRequest)
+        return
+    }
+
+    tx, err := cfg.db.BeginTx(r.Context(), nil)
+    if err != nil {
+        http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+        return
+    }
+    defer tx.Rollback()
+    qtx := cfg.DB.WithTx(tx)
+
+    userID, err := qtx.ConsumePasswordResetToken(r.Context(), auth.HashRefreshToken(p.Token))
+    if errors.Is(err, sql.ErrNoRows) {
+        http.Error(w, "Invalid or expired reset token", http.StatusBadRequest)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+        return
+    }
+
+    // Rolling back on a rejected password leaves the token usable for
+    // another try.
+    user, err := qtx.GetUserByID(r.Context(), userID)
+    if err != nil {
+        http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+        return
+    }
+    if !cfg.checkPassword(r.Context(), w, p.Password, user.Email) {
+        return
+    }
+
+    hashedPassword, err := auth.HashPassword(p.Password, cfg.passwordParams)
+    if err != nil {
+        http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+        return
+    }
+
+    err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+        ID:             userID,
+        HashedPassword: hashedPassword,
+    })
+    if err != nil {
+        http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+        return
+    }
+    if err := qtx.DeletePasswordResetTokens(r.Context(), userID); err != nil {
+        http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+        return
+    }
+    // Proving control of the email is enough to lift a lockout.
+    if _, err := qtx.ResetFailedLogins(r.Context(), userID); err != nil {
+        http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+        return
+    }
+
+    if err := tx.Commit(); err != nil {
+        http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+        return
+    }
+
+    // Whoever knew the old password must not stay logged in.
+    if err := cfg.revokeAllUserTokens(r.Context(), userID); err != nil {
+        http.Error(w, "Failed to revoke tokens", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
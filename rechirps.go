@@ -0,0 +1,180 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+
+    "github.com/google/uuid"
+    "github.com/lib/pq"
+
+    "github.com/danon29/chippy/internal/database"
+)
+
+// EmbeddedChirp is a chirp shown as part of another, such as the original of
+// a rechirp or an ancestor in a thread. Once the chirp is deleted, or hidden
+// from the viewer, only the tombstone flag is left: {"deleted": true}.
+type EmbeddedChirp struct {
+    *Chirp
+    Deleted bool `json:"deleted,omitempty"`
+}
+
+// isPlainRechirp reports whether chirp is a rechirp without a quote. Users
+// may hold at most one of those per original.
+func isPlainRechirp(chirp database.Chirp) bool {
+    return chirp.IsRepost && chirp.Body == ""
+}
+
+// maxRepostDepth is how many levels of originals are embedded. Quotes of
+// quotes nest; past this depth repost_of is left out.
+const maxRepostDepth = 3
+
+// attachReposts embeds the original of every rechirp in the batch, and the
+// originals of those in turn, up to maxRepostDepth levels.
+func (cfg *apiConfig) attachReposts(ctx context.Context, viewerID uuid.UUID, chirps []Chirp) error {
+    return cfg.attachRepostsToDepth(ctx, viewerID, chirps, maxRepostDepth)
+}
+
+func (cfg *apiConfig) attachRepostsToDepth(ctx context.Context, viewerID uuid.UUID, chirps []Chirp, depth int) error {
+    var ids []uuid.UUID
+    for _, chirp := range chirps {
+        if chirp.IsRepost && chirp.repostOfID.Valid {
+            ids = append(ids, chirp.repostOfID.UUID)
+        }
+    }
+
+    byID := make(map[uuid.UUID]*Chirp, len(ids))
+    if len(ids) > 0 {
+        rows, err := cfg.DB.GetChirpsByIDs(ctx, ids)
+        if err != nil {
+            return err
+        }
+
+        originals := make([]Chirp, 0, len(rows))
+        for _, row := range rows {
+            originals = append(originals, toChirp(row))
+        }
+
+        if err := cfg.attachLikes(ctx, viewerID, originals); err != nil {
+            return err
+        }
+        if depth > 1 {
+            if err := cfg.attachRepostsToDepth(ctx, viewerID, originals, depth-1); err != nil {
+                return err
+            }
+        }
+
+        for i := range originals {
+            byID[originals[i].ID] = &originals[i]
+        }
+    }
+
+    for i := range chirps {
+        if !chirps[i].IsRepost {
+            continue
+        }
+        original, ok := byID[chirps[i].repostOfID.UUID]
+        if !chirps[i].repostOfID.Valid || !ok || (original.hidden && original.UserId != viewerID) {
+            chirps[i].RepostOf = &EmbeddedChirp{Deleted: true}
+            continue
+        }
+        chirps[i].RepostOf = &EmbeddedChirp{Chirp: original}
+    }
+
+    return nil
+}
+
+func (cfg *apiConfig) rechirpHandler(w http.ResponseWriter, r *http.Request) {
+    type params struct {
+        Body string `json:"body"`
+    }
+
+    userID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return
+    }
+
+    chirpID, err := uuid.Parse(r.PathValue("chirpId"))
+    if err != nil {
+        http.Error(w, "Invalid chirp ID", http.StatusBadRequest)
+        return
+    }
+
+    // The body is optional: an empty request is a plain rechirp.
+    var p params
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+        http.Error(w, "Invalid request", http.StatusBadRequest)
+        return
+    }
+
+    cleaned, err := cfg.validateChirpBody(p.Body)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    original, err := cfg.DB.GetChirp(r.Context(), chirpID)
+    if err != nil || !visibleTo(original, userID) {
+        http.Error(w, "No such chirp", http.StatusNotFound)
+        return
+    }
+
+    // Rechirping a plain rechirp points at the chirp it reposted.
+    if isPlainRechirp(original) {
+        if !original.RepostOfID.Valid {
+            http.Error(w, "No such chirp", http.StatusNotFound)
+            return
+        }
+        original, err = cfg.DB.GetChirp(r.Context(), original.RepostOfID.UUID)
+        if err != nil || !visibleTo(original, userID) {
+            http.Error(w, "No such chirp", http.StatusNotFound)
+            return
+        }
+    }
+
+    tx, err := cfg.db.BeginTx(r.Context(), nil)
+    if err != nil {
+        http.Error(w, "Error while creating chirp", http.StatusInternalServerError)
+        return
+    }
+    defer tx.Rollback()
+
+    qtx := cfg.DB.WithTx(tx)
+
+    chirp, err := qtx.CreateRepost(r.Context(), database.CreateRepostParams{
+        Body:       cleaned,
+        UserID:     userID,
+        RepostOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+    })
+    if err != nil {
+        var pqErr *pq.Error
+        if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+            http.Error(w, "Chirp already rechirped", http.StatusConflict)
+            return
+        }
+        http.Error(w, "Error while creating chirp", http.StatusInternalServerError)
+        return
+    }
+
+    if err := indexChirpEntities(r.Context(), qtx, chirp); err != nil {
+        http.Error(w, "Error while creating chirp", http.StatusInternalServerError)
+        return
+    }
+
+    if err := tx.Commit(); err != nil {
+        http.Error(w, "Error while creating chirp", http.StatusInternalServerError)
+        return
+    }
+
+    result := []Chirp{toChirp(chirp)}
+    if err := cfg.hydrateChirps(r.Context(), userID, result); err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(result[0])
+}
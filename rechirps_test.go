@@ -0,0 +1,64 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "testing"
+
+    "github.com/google/uuid"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "github.com/danon29/chippy/internal/auth"
+    "github.com/danon29/chippy/internal/database"
+)
+
+func TestRechirp_EmbedsOriginals(t *testing.T) {
+    cfg := newTestConfig(t)
+    ctx := context.Background()
+    user := createTestUser(t, cfg)
+    token, _ := login(t, cfg, user)
+
+    quote := func(chirpID uuid.UUID, body string) Chirp {
+        rec := serve(cfg.middlewareRequireScope(auth.ScopeChirpsWrite, cfg.rechirpHandler).ServeHTTP,
+            http.MethodPost, "/api/chirps/"+chirpID.String()+"/rechirps", token, map[string]string{"body": body},
+            "chirpId", chirpID.String())
+        require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+        var chirp Chirp
+        require.NoError(t, json.NewDecoder(rec.Body).Decode(&chirp))
+        return chirp
+    }
+
+    original, err := cfg.DB.CreateChirp(ctx, database.CreateChirpParams{Body: "hello", UserID: user.ID})
+    require.NoError(t, err)
+    first := quote(original.ID, "quoting")
+    second := quote(first.ID, "quoting a quote")
+
+    require.NotNil(t, second.RepostOf)
+    require.NotNil(t, second.RepostOf.Chirp)
+    assert.Equal(t, first.ID, second.RepostOf.ID)
+    require.NotNil(t, second.RepostOf.RepostOf)
+    require.NotNil(t, second.RepostOf.RepostOf.Chirp)
+    assert.Equal(t, original.ID, second.RepostOf.RepostOf.ID)
+
+    t.Run("deleted original", func(t *testing.T) {
+        require.NoError(t, cfg.DB.DeleteChirp(ctx, original.ID))
+        row, err := cfg.DB.GetChirp(ctx, first.ID)
+        require.NoError(t, err)
+
+        chirps := []Chirp{toChirp(row)}
+        require.NoError(t, cfg.hydrateChirps(ctx, user.ID, chirps))
+
+        body, err := json.Marshal(chirps[0])
+        require.NoError(t, err)
+        assert.JSONEq(t, `{"deleted": true}`, repostOfJSON(t, body))
+    })
+}
+
+// repostOfJSON returns the repost_of member of an encoded chirp.
+func repostOfJSON(t *testing.T, body []byte) string {
+    var fields map[string]json.RawMessage
+    require.NoError(t, json.Unmarshal(body, &fields))
+    return string(fields["repost_of"])
+}
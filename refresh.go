@@ -0,0 +1,143 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/danon29/chippy/internal/auth"
+    "github.com/danon29/chippy/internal/database"
+)
+
+var errRefreshTokenReused = errors.New("refresh token reused")
+
+// refreshHandler trades a refresh token for a new access token and the next
+// refresh token in the same family. The presented token is revoked, so if it
+// ever shows up again someone else holds a copy: the whole session is
+// revoked, access tokens included, and both parties have to log in again.
+func (cfg *apiConfig) refreshHandler(w http.ResponseWriter, r *http.Request) {
+    presented, err := auth.GetBearerToken(r.Header)
+    if err != nil {
+        http.Error(w, "No valid tokens", http.StatusUnauthorized)
+        return
+    }
+
+    tx, err := cfg.db.BeginTx(r.Context(), nil)
+    if err != nil {
+        http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+        return
+    }
+    defer tx.Rollback()
+
+    qtx := cfg.DB.WithTx(tx)
+
+    refreshToken, err := qtx.GetRefreshToken(r.Context(), auth.HashRefreshToken(presented))
+    if err != nil {
+        http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+        return
+    }
+
+    // Every token in a family shares one expiry, so an expired token cannot
+    // be reused against a live family.
+    if time.Now().After(refreshToken.ExpiresAt) {
+        http.Error(w, "Refresh token expired", http.StatusUnauthorized)
+        return
+    }
+
+    newToken, err := rotateRefreshToken(r.Context(), qtx, refreshToken)
+    if errors.Is(err, errRefreshTokenReused) {
+        if err := qtx.RevokeRefreshTokenFamily(r.Context(), refreshToken.FamilyID); err != nil {
+            http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+            return
+        }
+        if err := tx.Commit(); err != nil {
+            http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+            return
+        }
+        if err := cfg.denylist.RevokeSession(r.Context(), refreshToken.FamilyID, cfg.maxAccessTokenTTL()); err != nil {
+            http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+            return
+        }
+        log.Printf("security: refresh token reuse for user %s, revoked token family %s (remote %s)",
+            refreshToken.UserID, refreshToken.FamilyID, r.RemoteAddr)
+        http.Error(w, "Refresh token revoked", http.StatusUnauthorized)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+        return
+    }
+
+    if err := qtx.TouchSession(r.Context(), refreshToken.FamilyID); err != nil {
+        http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+        return
+    }
+
+    user, err := qtx.GetUserByID(r.Context(), refreshToken.UserID)
+    if err != nil {
+        http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+        return
+    }
+
+    if user.BannedAt.Valid {
+        http.Error(w, "Account suspended", http.StatusForbidden)
+        return
+    }
+
+    newAccessToken, err := auth.MakeSessionJWT(user.ID, refreshToken.FamilyID, user.Role, cfg.jwtKeys, cfg.authConfig.AccessTokenTTL)
+    if err != nil {
+        http.Error(w, "Failed to generate access token", http.StatusInternalServerError)
+        return
+    }
+
+    if err := tx.Commit(); err != nil {
+        http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]string{
+        "token":         newAccessToken,
+        "refresh_token": newToken,
+    })
+}
+
+// rotateRefreshToken revokes old and issues its successor. The successor
+// keeps old's expiry, so rotating never extends a session past its login.
+// It returns errRefreshTokenReused if old was already revoked, including by
+// a concurrent refresh that got there first.
+func rotateRefreshToken(ctx context.Context, q *database.Queries, old database.RefreshToken) (string, error) {
+    if old.RevokedAt.Valid {
+        return "", errRefreshTokenReused
+    }
+
+    consumed, err := q.ConsumeRefreshToken(ctx, old.TokenHash)
+    if err != nil {
+        return "", err
+    }
+    if consumed == 0 {
+        return "", errRefreshTokenReused
+    }
+
+    token, err := auth.MakeRefreshToken()
+    if err != nil {
+        return "", err
+    }
+
+    _, err = q.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+        TokenHash: auth.HashRefreshToken(token),
+        UserID:    old.UserID,
+        ExpiresAt: old.ExpiresAt,
+        RevokedAt: sql.NullTime{Valid: false},
+        FamilyID:  old.FamilyID,
+    })
+    if err != nil {
+        return "", err
+    }
+
+    return token, nil
+}
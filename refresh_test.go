@@ -0,0 +1,35 @@
+package main
+
+import (
+    "encoding/json"
+    "net/http"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestRefresh_ReuseRevokesSessionAccessTokens(t *testing.T) {
+    cfg := newTestConfig(t)
+    user := createTestUser(t, cfg)
+    accessToken, refreshToken := login(t, cfg, user)
+
+    rec := serve(cfg.refreshHandler, http.MethodPost, "/api/refresh", refreshToken, nil)
+    require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+    var refreshed struct {
+        Token string `json:"token"`
+    }
+    require.NoError(t, json.NewDecoder(rec.Body).Decode(&refreshed))
+
+    rec = serve(cfg.listSessionsHandler, http.MethodGet, "/api/sessions", accessToken, nil)
+    require.Equal(t, http.StatusOK, rec.Code, "the access token works before the replay")
+
+    // Someone replays the rotated-out refresh token.
+    rec = serve(cfg.refreshHandler, http.MethodPost, "/api/refresh", refreshToken, nil)
+    require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+    for _, token := range []string{accessToken, refreshed.Token} {
+        rec = serve(cfg.listSessionsHandler, http.MethodGet, "/api/sessions", token, nil)
+        assert.Equal(t, http.StatusUnauthorized, rec.Code)
+    }
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+    "encoding/json"
+    "net/http"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/database"
+)
+
+func (cfg *apiConfig) chirpRepliesHandler(w http.ResponseWriter, r *http.Request) {
+    chirpID, err := uuid.Parse(r.PathValue("chirpId"))
+    if err != nil {
+        http.Error(w, "invalid chirpId", http.StatusBadRequest)
+        return
+    }
+
+    page, err := parsePageParams(r.URL.Query())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    viewerID := cfg.optionalUser(r)
+
+    parent, err := cfg.DB.GetChirp(r.Context(), chirpID)
+    if err != nil || !visibleTo(parent, viewerID) {
+        http.Error(w, "No such chirp", http.StatusNotFound)
+        return
+    }
+
+    cursorCreatedAt, cursorID := page.cursorArgs()
+    chirps, err := cfg.DB.GetChirpReplies(r.Context(), database.GetChirpRepliesParams{
+        ChirpID:         uuid.NullUUID{UUID: chirpID, Valid: true},
+        CursorCreatedAt: cursorCreatedAt,
+        CursorID:        cursorID,
+        PageSize:        page.Limit + 1,
+    })
+    if err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    resp := newChirpPage(chirps, page.Limit)
+    if err := cfg.hydrateChirps(r.Context(), viewerID, resp.Chirps); err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(resp)
+}
+
+// chirpThreadHandler returns the chirp together with every ancestor it replies
+// to, root first. Ancestors that were deleted or hidden from the viewer are
+// tombstones, {"deleted": true}; a deleted one ends the chain, since its own
+// parent is no longer known.
+func (cfg *apiConfig) chirpThreadHandler(w http.ResponseWriter, r *http.Request) {
+    chirpID, err := uuid.Parse(r.PathValue("chirpId"))
+    if err != nil {
+        http.Error(w, "invalid chirpId", http.StatusBadRequest)
+        return
+    }
+
+    rows, err := cfg.DB.GetChirpThread(r.Context(), chirpID)
+    if err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    viewerID := cfg.optionalUser(r)
+
+    // The requested chirp is the last row; hidden ancestors are left out.
+    if len(rows) == 0 || !visibleTo(database.Chirp(rows[len(rows)-1]), viewerID) {
+        http.Error(w, "No such chirp", http.StatusNotFound)
+        return
+    }
+
+    chirps := make([]Chirp, 0, len(rows))
+    for _, row := range rows {
+        if chirp := database.Chirp(row); visibleTo(chirp, viewerID) {
+            chirps = append(chirps, toChirp(chirp))
+        }
+    }
+
+    if err := cfg.hydrateChirps(r.Context(), viewerID, chirps); err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    thread := make([]EmbeddedChirp, 0, len(rows)+1)
+    // Replies keep their parent's ID after it is deleted, so a root that
+    // still replies to something means the walk stopped at a deleted chirp.
+    if rows[0].ReplyToID.Valid {
+        thread = append(thread, EmbeddedChirp{Deleted: true})
+    }
+    next := 0
+    for _, row := range rows {
+        if !visibleTo(database.Chirp(row), viewerID) {
+            thread = append(thread, EmbeddedChirp{Deleted: true})
+            continue
+        }
+        thread = append(thread, EmbeddedChirp{Chirp: &chirps[next]})
+        next++
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(thread)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "testing"
+
+    "github.com/google/uuid"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "github.com/danon29/chippy/internal/database"
+)
+
+func TestChirpThread_DeletedParent(t *testing.T) {
+    cfg := newTestConfig(t)
+    ctx := context.Background()
+    user := createTestUser(t, cfg)
+
+    post := func(body string, replyTo uuid.UUID) database.Chirp {
+        chirp, err := cfg.DB.CreateChirp(ctx, database.CreateChirpParams{
+            Body:      body,
+            UserID:    user.ID,
+            ReplyToID: uuid.NullUUID{UUID: replyTo, Valid: replyTo != uuid.Nil},
+        })
+        require.NoError(t, err)
+        return chirp
+    }
+    thread := func(chirpID uuid.UUID) []EmbeddedChirp {
+        rec := serve(cfg.chirpThreadHandler, http.MethodGet, "/api/chirps/"+chirpID.String()+"/thread", "", nil,
+            "chirpId", chirpID.String())
+        require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+        var entries []EmbeddedChirp
+        require.NoError(t, json.NewDecoder(rec.Body).Decode(&entries))
+        return entries
+    }
+
+    root := post("root", uuid.Nil)
+    parent := post("parent", root.ID)
+    reply := post("reply", parent.ID)
+    require.Len(t, thread(reply.ID), 3)
+
+    require.NoError(t, cfg.DB.DeleteChirp(ctx, parent.ID))
+
+    entries := thread(reply.ID)
+    require.Len(t, entries, 2)
+    assert.True(t, entries[0].Deleted)
+    assert.Nil(t, entries[0].Chirp)
+    require.NotNil(t, entries[1].Chirp)
+    assert.Equal(t, reply.ID, entries[1].ID)
+    require.NotNil(t, entries[1].ReplyToID)
+    assert.Equal(t, parent.ID, *entries[1].ReplyToID)
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "time"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/database"
+)
+
+const maxReportDetailsLength = 500
+
+var reportReasons = map[string]bool{
+    "spam":           true,
+    "harassment":     true,
+    "hate_speech":    true,
+    "violence":       true,
+    "sexual_content": true,
+    "misinformation": true,
+    "other":          true,
+}
+
+var reportStatuses = map[string]bool{
+    "open":      true,
+    "hidden":    true,
+    "deleted":   true,
+    "dismissed": true,
+}
+
+type Report struct {
+    ID          uuid.UUID  `json:"id"`
+    CreatedAt   time.Time  `json:"created_at"`
+    ChirpID     *uuid.UUID `json:"chirp_id"`
+    ReporterID  uuid.UUID  `json:"reporter_id"`
+    Reason      string     `json:"reason"`
+    Details     string     `json:"details,omitempty"`
+    Status      string     `json:"status"`
+    ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+    ChirpBody   *string    `json:"chirp_body,omitempty"`
+    ChirpUserID *uuid.UUID `json:"chirp_user_id,omitempty"`
+}
+
+type ReportPage struct {
+    Reports    []Report `json:"reports"`
+    NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) reportChirpHandler(w http.ResponseWriter, r *http.Request) {
+    type params struct {
+        Reason  string `json:"reason"`
+        Details string `json:"details"`
+    }
+
+    userID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return
+    }
+
+    chirpID, err := uuid.Parse(r.PathValue("chirpId"))
+    if err != nil {
+        http.Error(w, "Invalid chirp ID", http.StatusBadRequest)
+        return
+    }
+
+    var p params
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, "Invalid request", http.StatusBadRequest)
+        return
+    }
+
+    if !reportReasons[p.Reason] {
+        http.Error(w, "Unknown report reason", http.StatusBadRequest)
+        return
+    }
+
+    if len(p.Details) > maxReportDetailsLength {
+        http.Error(w, "Report details are too long", http.StatusBadRequest)
+        return
+    }
+
+    chirp, err := cfg.DB.GetChirp(r.Context(), chirpID)
+    if err != nil || !visibleTo(chirp, userID) {
+        http.Error(w, "No such chirp", http.StatusNotFound)
+        return
+    }
+
+    if chirp.UserID == userID {
+        http.Error(w, "Users cannot report their own chirps", http.StatusBadRequest)
+        return
+    }
+
+    report, err := cfg.DB.CreateReport(r.Context(), database.CreateReportParams{
+        ChirpID:    uuid.NullUUID{UUID: chirp.ID, Valid: true},
+        ReporterID: userID,
+        Reason:     p.Reason,
+        Details:    p.Details,
+    })
+    if err != nil {
+        // The insert skips duplicates, so no row means this user already reported it.
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Chirp already reported", http.StatusConflict)
+            return
+        }
+        http.Error(w, "Failed to report chirp", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(Report{
+        ID:         report.ID,
+        CreatedAt:  report.CreatedAt,
+        ChirpID:    &chirp.ID,
+        ReporterID: report.ReporterID,
+        Reason:     report.Reason,
+        Details:    report.Details,
+        Status:     report.Status,
+    })
+}
+
+func (cfg *apiConfig) listReportsHandler(w http.ResponseWriter, r *http.Request) {
+    status := r.URL.Query().Get("status")
+    if status == "" {
+        status = "open"
+    }
+    if !reportStatuses[status] {
+        http.Error(w, "Unknown report status", http.StatusBadRequest)
+        return
+    }
+
+    page, err := parsePageParams(r.URL.Query())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    cursorCreatedAt, cursorID := page.cursorArgs()
+    rows, err := cfg.DB.ListReports(r.Context(), database.ListReportsParams{
+        Status:          status,
+        CursorCreatedAt: cursorCreatedAt,
+        CursorID:        cursorID,
+        PageSize:        page.Limit + 1,
+    })
+    if err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    resp := ReportPage{Reports: make([]Report, 0, len(rows))}
+
+    if len(rows) > int(page.Limit) {
+        rows = rows[:page.Limit]
+        last := rows[len(rows)-1]
+        resp.NextCursor = encodeCursor(cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+    }
+
+    for _, row := range rows {
+        report := Report{
+            ID:         row.ID,
+            CreatedAt:  row.CreatedAt,
+            ReporterID: row.ReporterID,
+            Reason:     row.Reason,
+            Details:    row.Details,
+            Status:     row.Status,
+        }
+        if row.ChirpID.Valid {
+            report.ChirpID = &row.ChirpID.UUID
+        }
+        if row.ResolvedAt.Valid {
+            report.ResolvedAt = &row.ResolvedAt.Time
+        }
+        if row.ChirpBody.Valid {
+            report.ChirpBody = &row.ChirpBody.String
+        }
+        if row.ChirpUserID.Valid {
+            report.ChirpUserID = &row.ChirpUserID.UUID
+        }
+        resp.Reports = append(resp.Reports, report)
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(resp)
+}
+
+// resolveReportHandler applies a moderator decision. The decision closes every
+// open report against the same chirp, not just the one it was made on.
+func (cfg *apiConfig) resolveReportHandler(w http.ResponseWriter, r *http.Request) {
+    type params struct {
+        Action string `json:"action"`
+    }
+
+    reportID, err := uuid.Parse(r.PathValue("reportID"))
+    if err != nil {
+        http.Error(w, "Invalid report ID", http.StatusBadRequest)
+        return
+    }
+
+    var p params
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, "Invalid request", http.StatusBadRequest)
+        return
+    }
+
+    var status string
+    switch p.Action {
+    case "hide":
+        status = "hidden"
+    case "delete":
+        status = "deleted"
+    case "dismiss":
+        status = "dismissed"
+    default:
+        http.Error(w, "action must be one of hide, delete, dismiss", http.StatusBadRequest)
+        return
+    }
+
+    tx, err := cfg.db.BeginTx(r.Context(), nil)
+    if err != nil {
+        http.Error(w, "Failed to resolve report", http.StatusInternalServerError)
+        return
+    }
+    defer tx.Rollback()
+
+    qtx := cfg.DB.WithTx(tx)
+
+    report, err := qtx.GetReport(r.Context(), reportID)
+    if err != nil {
+        http.Error(w, "No such report", http.StatusNotFound)
+        return
+    }
+
+    if report.Status != "open" {
+        http.Error(w, "Report already resolved", http.StatusConflict)
+        return
+    }
+
+    if status != "dismissed" && !report.ChirpID.Valid {
+        http.Error(w, "Reported chirp no longer exists", http.StatusConflict)
+        return
+    }
+
+    err = qtx.ResolveReports(r.Context(), database.ResolveReportsParams{
+        Status:     status,
+        ResolvedBy: uuid.NullUUID{UUID: userIDFromContext(r.Context()), Valid: true},
+        ID:         report.ID,
+        ChirpID:    report.ChirpID,
+    })
+    if err != nil {
+        http.Error(w, "Failed to resolve report", http.StatusInternalServerError)
+        return
+    }
+
+    switch status {
+    case "hidden":
+        err = qtx.HideChirp(r.Context(), report.ChirpID.UUID)
+    case "deleted":
+        err = qtx.DeleteChirp(r.Context(), report.ChirpID.UUID)
+    }
+    if err != nil {
+        http.Error(w, "Failed to resolve report", http.StatusInternalServerError)
+        return
+    }
+
+    if err := tx.Commit(); err != nil {
+        http.Error(w, "Failed to resolve report", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "net/http"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/auth"
+    "github.com/danon29/chippy/internal/database"
+)
+
+// setUserRoleHandler changes a user's role. The user's tokens are revoked,
+// so the new role takes effect as soon as they log in again.
+func (cfg *apiConfig) setUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+    type params struct {
+        Role string `json:"role"`
+    }
+
+    userID, err := uuid.Parse(r.PathValue("userID"))
+    if err != nil {
+        http.Error(w, "Invalid user ID", http.StatusBadRequest)
+        return
+    }
+
+    var p params
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, "Invalid JSON", http.StatusBadRequest)
+        return
+    }
+
+    if !auth.ValidRole(p.Role) {
+        http.Error(w, "Invalid role", http.StatusBadRequest)
+        return
+    }
+
+    if userID == userIDFromContext(r.Context()) && p.Role != auth.RoleAdmin {
+        http.Error(w, "Admins cannot demote themselves", http.StatusBadRequest)
+        return
+    }
+
+    user, err := cfg.DB.SetUserRole(r.Context(), database.SetUserRoleParams{
+        ID:   userID,
+        Role: p.Role,
+    })
+    if errors.Is(err, sql.ErrNoRows) {
+        http.Error(w, "User not found", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to update role", http.StatusInternalServerError)
+        return
+    }
+
+    // Tokens carry the role, so existing ones would keep the old one.
+    if err := cfg.revokeAllUserTokens(r.Context(), userID); err != nil {
+        http.Error(w, "Failed to revoke tokens", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(User{
+        ID:          user.ID,
+        CreatedAt:   user.CreatedAt,
+        UpdatedAt:   user.UpdatedAt,
+        Email:       user.Email,
+        IsChirpyRed: user.IsChirpyRed,
+        Role:        user.Role,
+        EmailVerified: user.EmailVerifiedAt.Valid,
+    })
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/database"
+    "github.com/danon29/chippy/internal/search"
+)
+
+type SearchResult struct {
+    Chirp
+    Rank    float32 `json:"rank"`
+    // Snippet is HTML: the chirp text is escaped and matches are wrapped
+    // in <mark> tags.
+    Snippet string  `json:"snippet"`
+}
+
+func (cfg *apiConfig) searchChirpsHandler(w http.ResponseWriter, r *http.Request) {
+    type searchResponse struct {
+        Results    []SearchResult `json:"results"`
+        NextOffset *int           `json:"next_offset,omitempty"`
+    }
+
+    tsQuery, err := search.BuildTSQuery(r.URL.Query().Get("q"))
+    if err != nil {
+        http.Error(w, "q is required", http.StatusBadRequest)
+        return
+    }
+
+    page, err := parsePageParams(r.URL.Query())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    offset := 0
+    if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+        offset, err = strconv.Atoi(offsetStr)
+        if err != nil || offset < 0 {
+            http.Error(w, "invalid offset", http.StatusBadRequest)
+            return
+        }
+    }
+
+    var authorID uuid.NullUUID
+    if authorIDStr := r.URL.Query().Get("author_id"); authorIDStr != "" {
+        id, err := uuid.Parse(authorIDStr)
+        if err != nil {
+            http.Error(w, "Invalid author ID format", http.StatusBadRequest)
+            return
+        }
+        authorID = uuid.NullUUID{UUID: id, Valid: true}
+    }
+
+    rows, err := cfg.DB.SearchChirps(r.Context(), database.SearchChirpsParams{
+        Query:           tsQuery,
+        HeadlineOptions: search.HeadlineOptions,
+        AuthorID:        authorID,
+        PageSize:        page.Limit + 1,
+        PageOffset:      int32(offset),
+    })
+    if err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    resp := searchResponse{Results: make([]SearchResult, 0, len(rows))}
+
+    if len(rows) > int(page.Limit) {
+        rows = rows[:page.Limit]
+        next := offset + len(rows)
+        resp.NextOffset = &next
+    }
+
+    chirps := make([]Chirp, 0, len(rows))
+    for _, row := range rows {
+        chirps = append(chirps, toChirp(database.Chirp{
+            ID:         row.ID,
+            CreatedAt:  row.CreatedAt,
+            UpdatedAt:  row.UpdatedAt,
+            Body:       row.Body,
+            UserID:     row.UserID,
+            ReplyToID:  row.ReplyToID,
+            RepostOfID: row.RepostOfID,
+            IsRepost:   row.IsRepost,
+        }))
+    }
+
+    if err := cfg.hydrateChirps(r.Context(), cfg.optionalUser(r), chirps); err != nil {
+        http.Error(w, "Error", http.StatusInternalServerError)
+        return
+    }
+
+    for i, row := range rows {
+        resp.Results = append(resp.Results, SearchResult{
+            Chirp:   chirps[i],
+            Rank:    row.Rank,
+            Snippet: search.Highlight(row.Snippet),
+        })
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/url"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "github.com/danon29/chippy/internal/database"
+)
+
+func TestSearchChirps_Snippet(t *testing.T) {
+    cfg := newTestConfig(t)
+    user := createTestUser(t, cfg)
+    _, err := cfg.DB.CreateChirp(context.Background(), database.CreateChirpParams{
+        Body:   "fish & chips <3",
+        UserID: user.ID,
+    })
+    require.NoError(t, err)
+
+    search := func(q string) []SearchResult {
+        rec := serve(cfg.searchChirpsHandler, http.MethodGet, "/api/chirps/search?q="+url.QueryEscape(q), "", nil)
+        require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+        var resp struct {
+            Results []SearchResult `json:"results"`
+        }
+        require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+        return resp.Results
+    }
+
+    results := search("fish & chips")
+    require.Len(t, results, 1)
+    assert.Equal(t, "<mark>fish</mark> &amp; <mark>chips</mark> &lt;3", results[0].Snippet)
+
+    // The escaped body is never searched, so entity names don't match.
+    assert.Empty(t, search("amp"))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+    "encoding/json"
+    "net"
+    "net/http"
+    "time"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/database"
+)
+
+const maxUserAgentLength = 512
+
+type Session struct {
+    ID         uuid.UUID `json:"id"`
+    CreatedAt  time.Time `json:"created_at"`
+    LastUsedAt time.Time `json:"last_used_at"`
+    UserAgent  string    `json:"user_agent"`
+    IPAddress  string    `json:"ip_address"`
+}
+
+// clientIP returns the address the request came from, without the port.
+func clientIP(r *http.Request) string {
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        return r.RemoteAddr
+    }
+    return host
+}
+
+// newSession records a login from the device making r, using q so the
+// caller can include it in a transaction.
+func newSession(q *database.Queries, r *http.Request, userID uuid.UUID) (database.Session, error) {
+    userAgent := r.UserAgent()
+    if len(userAgent) > maxUserAgentLength {
+        userAgent = userAgent[:maxUserAgentLength]
+    }
+
+    return q.CreateSession(r.Context(), database.CreateSessionParams{
+        UserID:    userID,
+        UserAgent: userAgent,
+        IpAddress: clientIP(r),
+    })
+}
+
+func (cfg *apiConfig) listSessionsHandler(w http.ResponseWriter, r *http.Request) {
+    userID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return
+    }
+
+    rows, err := cfg.DB.ListActiveSessions(r.Context(), userID)
+    if err != nil {
+        http.Error(w, "Failed to list sessions", http.StatusInternalServerError)
+        return
+    }
+
+    sessions := make([]Session, 0, len(rows))
+    for _, row := range rows {
+        sessions = append(sessions, Session{
+            ID:         row.ID,
+            CreatedAt:  row.CreatedAt,
+            LastUsedAt: row.LastUsedAt,
+            UserAgent:  row.UserAgent,
+            IPAddress:  row.IpAddress,
+        })
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(sessions)
+}
+
+// revokeSessionHandler logs one device out: its refresh tokens are revoked
+// and the access tokens issued to it are denied.
+func (cfg *apiConfig) revokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+    userID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return
+    }
+
+    sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+    if err != nil {
+        http.Error(w, "Invalid session ID", http.StatusBadRequest)
+        return
+    }
+
+    revoked, err := cfg.DB.RevokeSession(r.Context(), database.RevokeSessionParams{
+        FamilyID: sessionID,
+        UserID:   userID,
+    })
+    if err != nil {
+        http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+        return
+    }
+    if revoked == 0 {
+        http.Error(w, "No such session", http.StatusNotFound)
+        return
+    }
+
+    if err := cfg.denylist.RevokeSession(r.Context(), sessionID, cfg.maxAccessTokenTTL()); err != nil {
+        http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeAllSessionsHandler logs the caller out everywhere, denying their
+// access tokens as well as their refresh tokens.
+func (cfg *apiConfig) revokeAllSessionsHandler(w http.ResponseWriter, r *http.Request) {
+    userID, ok := cfg.requireUser(w, r)
+    if !ok {
+        return
+    }
+
+    if err := cfg.revokeAllUserTokens(r.Context(), userID); err != nil {
+        http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+    "net/http"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "github.com/danon29/chippy/internal/auth"
+)
+
+func TestRevokeAllSessions_LoginAgainInSameSecond(t *testing.T) {
+    cfg := newTestConfig(t)
+    user := createTestUser(t, cfg)
+    oldToken, _ := login(t, cfg, user)
+
+    rec := serve(cfg.middlewareRequireScope(auth.ScopeUsersWrite, cfg.revokeAllSessionsHandler).ServeHTTP,
+        http.MethodDelete, "/api/sessions", oldToken, nil)
+    require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+    // Well within a second of the revocation.
+    newToken, _ := login(t, cfg, user)
+
+    rec = serve(cfg.listSessionsHandler, http.MethodGet, "/api/sessions", newToken, nil)
+    assert.Equal(t, http.StatusOK, rec.Code, "the new login works")
+
+    rec = serve(cfg.listSessionsHandler, http.MethodGet, "/api/sessions", oldToken, nil)
+    assert.Equal(t, http.StatusUnauthorized, rec.Code, "the old token is revoked")
+}
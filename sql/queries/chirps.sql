@@ -1,6 +1,6 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, reply_to_id)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3)
 RETURNING *;
 
 -- name: DeleteChirps :exec
@@ -20,4 +20,89 @@ WHERE id = $1;
 
 -- name: GetChirpByUserId :many
 SELECT * FROM chirps
-WHERE user_id = $1;
\ No newline at end of file
+WHERE user_id = $1;
+
+-- name: ListChirpsAsc :many
+SELECT * FROM chirps
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (created_at, id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND (hidden_at IS NULL OR user_id = sqlc.narg('viewer_id'))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND (hidden_at IS NULL OR user_id = sqlc.narg('viewer_id'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: SearchChirps :many
+SELECT id, created_at, updated_at, body, user_id, reply_to_id, repost_of_id, is_repost,
+       ts_rank(search_vector, to_tsquery('english', sqlc.arg('query')))::real AS rank,
+       ts_headline('english', body, to_tsquery('english', sqlc.arg('query')),
+                   sqlc.arg('headline_options')::text)::text AS snippet
+FROM chirps
+WHERE search_vector @@ to_tsquery('english', sqlc.arg('query'))
+  AND hidden_at IS NULL
+  AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT sqlc.arg('page_size') OFFSET sqlc.arg('page_offset');
+
+-- name: GetChirpForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateChirp :one
+UPDATE chirps
+SET body = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateChirpRevision :one
+INSERT INTO chirp_revisions (id, chirp_id, body, created_at, replaced_at)
+VALUES (gen_random_uuid(), $1, $2, $3, NOW())
+RETURNING *;
+
+-- name: GetChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY replaced_at;
+
+-- name: GetChirpReplies :many
+SELECT * FROM chirps
+WHERE reply_to_id = sqlc.arg('chirp_id')
+  AND hidden_at IS NULL
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (created_at, id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetChirpThread :many
+WITH RECURSIVE ancestors AS (
+    SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.reply_to_id, chirps.repost_of_id, chirps.is_repost, chirps.hidden_at, 0 AS depth FROM chirps
+    WHERE chirps.id = $1
+    UNION ALL
+    SELECT parent.id, parent.created_at, parent.updated_at, parent.body, parent.user_id, parent.search_vector, parent.reply_to_id, parent.repost_of_id, parent.is_repost, parent.hidden_at, ancestors.depth + 1 FROM chirps parent
+    JOIN ancestors ON parent.id = ancestors.reply_to_id
+)
+SELECT id, created_at, updated_at, body, user_id, search_vector, reply_to_id, repost_of_id, is_repost, hidden_at FROM ancestors
+ORDER BY depth DESC;
+
+-- name: CreateRepost :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, repost_of_id, is_repost)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, true)
+RETURNING *;
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: HideChirp :exec
+UPDATE chirps
+SET hidden_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,40 @@
+-- name: DenyToken :exec
+INSERT INTO denied_tokens (jti, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: ListDeniedTokens :many
+SELECT * FROM denied_tokens
+WHERE expires_at > NOW();
+
+-- name: DeleteExpiredDeniedTokens :exec
+DELETE FROM denied_tokens
+WHERE expires_at <= NOW();
+
+-- name: RevokeUserTokens :exec
+INSERT INTO user_token_revocations (user_id, revoked_before, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET revoked_before = EXCLUDED.revoked_before,
+    expires_at = EXCLUDED.expires_at;
+
+-- name: ListUserTokenRevocations :many
+SELECT * FROM user_token_revocations
+WHERE expires_at > NOW();
+
+-- name: DeleteExpiredUserTokenRevocations :exec
+DELETE FROM user_token_revocations
+WHERE expires_at <= NOW();
+
+-- name: DenySession :exec
+INSERT INTO denied_sessions (session_id, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (session_id) DO NOTHING;
+
+-- name: ListDeniedSessions :many
+SELECT * FROM denied_sessions
+WHERE expires_at > NOW();
+
+-- name: DeleteExpiredDeniedSessions :exec
+DELETE FROM denied_sessions
+WHERE expires_at <= NOW();
@@ -0,0 +1,17 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (jti, user_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: ConsumeEmailVerificationToken :execrows
+UPDATE email_verification_tokens
+SET used_at = NOW()
+WHERE jti = $1 AND user_id = $2 AND used_at IS NULL;
+
+-- name: MarkEmailVerified :execrows
+UPDATE users
+SET email_verified_at = COALESCE(email_verified_at, NOW())
+WHERE id = $1 AND email = $2;
+
+-- name: DeleteExpiredEmailVerificationTokens :exec
+DELETE FROM email_verification_tokens
+WHERE user_id = $1 AND expires_at <= NOW();
@@ -0,0 +1,34 @@
+-- name: FollowUser :exec
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :exec
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT follower_id, created_at FROM follows
+WHERE followee_id = sqlc.arg('user_id')
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (created_at, follower_id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, follower_id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetFollowing :many
+SELECT followee_id, created_at FROM follows
+WHERE follower_id = sqlc.arg('user_id')
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (created_at, followee_id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, followee_id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetTimeline :many
+SELECT chirps.* FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE follows.follower_id = sqlc.arg('user_id')
+  AND chirps.hidden_at IS NULL
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
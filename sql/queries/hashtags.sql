@@ -0,0 +1,35 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags (id, tag, created_at)
+VALUES (gen_random_uuid(), $1, NOW())
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING *;
+
+-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: GetHashtagChirps :many
+SELECT chirps.* FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = sqlc.arg('tag')
+  AND chirps.hidden_at IS NULL
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetTrendingHashtags :many
+SELECT hashtags.tag, COUNT(*) AS chirp_count
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id AND chirps.hidden_at IS NULL
+WHERE chirps.created_at > sqlc.arg('since')
+GROUP BY hashtags.tag
+ORDER BY chirp_count DESC, hashtags.tag
+LIMIT sqlc.arg('page_size');
@@ -0,0 +1,19 @@
+-- name: AddMentions :exec
+INSERT INTO mentions (chirp_id, user_id, created_at)
+SELECT sqlc.arg('chirp_id'), users.id, NOW() FROM users
+WHERE lower(users.email) = ANY(sqlc.arg('emails')::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1;
+
+-- name: GetUserMentions :many
+SELECT chirps.* FROM chirps
+JOIN mentions ON mentions.chirp_id = chirps.id
+WHERE mentions.user_id = sqlc.arg('user_id')
+  AND chirps.hidden_at IS NULL
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
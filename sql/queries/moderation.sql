@@ -0,0 +1,12 @@
+-- name: ListBannedTerms :many
+SELECT term FROM banned_terms
+ORDER BY term;
+
+-- name: AddBannedTerm :exec
+INSERT INTO banned_terms (term, created_at)
+VALUES ($1, NOW())
+ON CONFLICT (term) DO NOTHING;
+
+-- name: RemoveBannedTerm :exec
+DELETE FROM banned_terms
+WHERE term = $1;
@@ -0,0 +1,25 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, chirp_id, reporter_id, reason, details)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4)
+ON CONFLICT (chirp_id, reporter_id) DO NOTHING
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM reports
+WHERE id = $1;
+
+-- name: ListReports :many
+SELECT reports.*, chirps.body AS chirp_body, chirps.user_id AS chirp_user_id
+FROM reports
+LEFT JOIN chirps ON chirps.id = reports.chirp_id
+WHERE reports.status = sqlc.arg('status')
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (reports.created_at, reports.id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY reports.created_at ASC, reports.id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ResolveReports :exec
+UPDATE reports
+SET status = sqlc.arg('status'), resolved_at = NOW(), resolved_by = sqlc.narg('resolved_by')
+WHERE status = 'open'
+  AND (id = sqlc.arg('id') OR chirp_id = sqlc.narg('chirp_id'));
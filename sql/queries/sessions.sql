@@ -0,0 +1,35 @@
+-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, created_at, last_used_at, user_agent, ip_address)
+VALUES (gen_random_uuid(), $1, NOW(), NOW(), $2, $3)
+RETURNING *;
+
+-- name: TouchSession :exec
+UPDATE sessions SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: ListActiveSessions :many
+SELECT * FROM sessions
+WHERE user_id = $1
+  AND EXISTS (
+      SELECT 1 FROM refresh_tokens
+      WHERE refresh_tokens.family_id = sessions.id
+        AND refresh_tokens.revoked_at IS NULL
+        AND refresh_tokens.expires_at > NOW()
+  )
+ORDER BY last_used_at DESC;
+
+-- name: ListUserSessionIDs :many
+SELECT id FROM sessions
+WHERE user_id = $1;
+
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
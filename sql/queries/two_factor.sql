@@ -0,0 +1,33 @@
+-- name: SetPendingTOTPSecret :execrows
+INSERT INTO totp_credentials (user_id, secret, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, created_at = NOW()
+WHERE totp_credentials.confirmed_at IS NULL;
+
+-- name: GetTOTPCredential :one
+SELECT * FROM totp_credentials
+WHERE user_id = $1;
+
+-- name: ConfirmTOTPCredential :execrows
+UPDATE totp_credentials
+SET confirmed_at = NOW(), last_used_step = $2
+WHERE user_id = $1 AND confirmed_at IS NULL;
+
+-- name: UseTOTPStep :execrows
+UPDATE totp_credentials
+SET last_used_step = $2
+WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, code_hash, created_at)
+VALUES ($1, $2, NOW());
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: ConsumeRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
@@ -11,27 +11,36 @@ SELECT * FROM users
 WHERE email = $1;
 
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
-VALUES ($1, NOW(), NOW(), $2, $3, $4)
+INSERT INTO refresh_tokens (token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id)
+VALUES ($1, NOW(), NOW(), $2, $3, $4, $5)
 RETURNING *;
 
--- name: RevokeRefreshToken :exec
+-- name: RevokeRefreshToken :execrows
 UPDATE refresh_tokens 
 SET revoked_at = NOW(), 
     updated_at = NOW()
-WHERE token = $1;
+WHERE token_hash = $1;
 
 -- name: GetRefreshToken :one
 SELECT * FROM refresh_tokens
-WHERE token = $1;
+WHERE token_hash = $1;
 
--- name: UpdateRefreshToken :exec
-UPDATE refresh_tokens SET updated_at = NOW() 
-WHERE token = $1;
+-- name: ConsumeRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE token_hash = $1 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
 
 -- name: UpdateUser :one
 UPDATE users
-SET email = $2, hashed_password = $3, updated_at = NOW()
+SET email = $2, hashed_password = $3, updated_at = NOW(),
+    email_verified_at = CASE WHEN email = $2 THEN email_verified_at END
 WHERE id = $1
 RETURNING *;
 
@@ -39,4 +48,46 @@ RETURNING *;
 UPDATE users
 SET is_chirpy_red = true, updated_at = NOW()
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: SetUserRole :one
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: BanUser :one
+UPDATE users
+SET banned_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UnbanUser :one
+UPDATE users
+SET banned_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET hashed_password = $2
+WHERE id = $1;
+
+-- name: RecordFailedLogin :one
+UPDATE users
+SET failed_login_count = CASE
+        WHEN last_failed_login_at IS NULL OR last_failed_login_at < sqlc.arg('window_start')::timestamptz THEN 1
+        ELSE failed_login_count + 1
+    END,
+    last_failed_login_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING failed_login_count;
+
+-- name: ResetFailedLogins :execrows
+UPDATE users
+SET failed_login_count = 0, last_failed_login_at = NULL
+WHERE id = $1;
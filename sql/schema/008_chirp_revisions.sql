@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE chirp_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    replaced_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX chirp_revisions_chirp_id_idx ON chirp_revisions (chirp_id, replaced_at);
+
+-- +goose Down
+DROP TABLE chirp_revisions;
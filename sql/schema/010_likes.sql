@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE likes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (user_id, chirp_id)
+);
+
+CREATE INDEX likes_chirp_id_idx ON likes (chirp_id);
+
+-- +goose Down
+DROP TABLE likes;
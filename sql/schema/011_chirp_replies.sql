@@ -0,0 +1,9 @@
+-- +goose Up
+-- Replies outlive their parent: deleting a chirp detaches its replies instead
+-- of cascading through the whole thread.
+ALTER TABLE chirps ADD COLUMN reply_to_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_reply_to_id_idx ON chirps (reply_to_id, created_at, id);
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN reply_to_id;
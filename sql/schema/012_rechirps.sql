@@ -0,0 +1,17 @@
+-- +goose Up
+-- A rechirp keeps is_repost after its original is deleted, so the API can
+-- render a tombstone in place of the embedded chirp.
+ALTER TABLE chirps
+    ADD COLUMN repost_of_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    ADD COLUMN is_repost BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX chirps_repost_of_id_idx ON chirps (repost_of_id);
+
+-- A user can rechirp a chirp only once; quote chirps are not limited.
+CREATE UNIQUE INDEX chirps_user_id_repost_of_id_idx ON chirps (user_id, repost_of_id)
+    WHERE is_repost AND body = '';
+
+-- +goose Down
+DROP INDEX chirps_user_id_repost_of_id_idx;
+ALTER TABLE chirps DROP COLUMN is_repost;
+ALTER TABLE chirps DROP COLUMN repost_of_id;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE banned_terms (
+    term TEXT PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+INSERT INTO banned_terms (term, created_at) VALUES
+    ('kerfuffle', NOW()),
+    ('sharbert', NOW()),
+    ('fornax', NOW());
+
+-- +goose Down
+DROP TABLE banned_terms;
@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+
+-- Reports outlive deleted chirps so moderation decisions stay auditable.
+CREATE TABLE reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open',
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE (chirp_id, reporter_id),
+    CHECK (status IN ('open', 'hidden', 'deleted', 'dismissed'))
+);
+
+CREATE INDEX reports_status_created_at_idx ON reports (status, created_at, id);
+
+-- +goose Down
+DROP TABLE reports;
+ALTER TABLE chirps DROP COLUMN hidden_at;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Every refresh token belongs to a family started at login. Refreshing
+-- revokes the presented token and issues the next one in the same family, so
+-- seeing a revoked token again means it was stolen and the family is revoked.
+ALTER TABLE refresh_tokens ADD COLUMN family_id UUID;
+UPDATE refresh_tokens SET family_id = gen_random_uuid();
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+-- +goose Down
+DROP INDEX refresh_tokens_family_id_idx;
+ALTER TABLE refresh_tokens DROP COLUMN family_id;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Refresh tokens are stored as the hex SHA-256 of the token handed to the
+-- client. Existing rows are re-keyed in place so live sessions survive.
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+UPDATE refresh_tokens SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
+
+-- +goose Down
+-- Digests cannot be turned back into tokens, so every session is dropped.
+DELETE FROM refresh_tokens;
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- A session is one login on one device. Its id is the family_id shared by
+-- the refresh tokens rotated from that login.
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+
+INSERT INTO sessions (id, user_id, created_at, last_used_at)
+SELECT family_id, user_id, MIN(created_at), MAX(updated_at)
+FROM refresh_tokens
+GROUP BY family_id, user_id;
+
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT refresh_tokens_family_id_fkey
+    FOREIGN KEY (family_id) REFERENCES sessions(id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE refresh_tokens DROP CONSTRAINT refresh_tokens_family_id_fkey;
+DROP TABLE sessions;
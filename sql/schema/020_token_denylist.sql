@@ -0,0 +1,22 @@
+-- +goose Up
+-- Access tokens revoked before they expire. Rows can be deleted once
+-- expires_at has passed, since the tokens they deny are dead anyway.
+CREATE TABLE denied_tokens (
+    jti TEXT PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Revokes every access token issued to a user up to revoked_before, for
+-- password changes, "log out everywhere" and bans.
+CREATE TABLE user_token_revocations (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    revoked_before TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+ALTER TABLE users ADD COLUMN banned_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN banned_at;
+DROP TABLE user_token_revocations;
+DROP TABLE denied_tokens;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN failed_login_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_failed_login_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN last_failed_login_at,
+    DROP COLUMN failed_login_count;
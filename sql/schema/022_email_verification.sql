@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE email_verification_tokens (
+    jti TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX email_verification_tokens_user_id_idx ON email_verification_tokens (user_id);
+
+-- +goose Down
+DROP TABLE email_verification_tokens;
+ALTER TABLE users DROP COLUMN email_verified_at;
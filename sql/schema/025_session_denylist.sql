@@ -0,0 +1,10 @@
+-- +goose Up
+-- Revokes every access token issued to a session when that one device is
+-- logged out. Rows can be deleted once expires_at has passed.
+CREATE TABLE denied_sessions (
+    session_id UUID PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- +goose Down
+DROP TABLE denied_sessions;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Replies keep the ID of a deleted parent, so a thread can show that a chirp
+-- is missing rather than turning its replies into top-level chirps.
+ALTER TABLE chirps DROP CONSTRAINT chirps_reply_to_id_fkey;
+
+-- +goose Down
+UPDATE chirps SET reply_to_id = NULL
+WHERE reply_to_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM chirps parent WHERE parent.id = chirps.reply_to_id);
+ALTER TABLE chirps ADD CONSTRAINT chirps_reply_to_id_fkey
+    FOREIGN KEY (reply_to_id) REFERENCES chirps(id) ON DELETE SET NULL;
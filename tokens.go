@@ -0,0 +1,98 @@
+package main
+
+import (
+    "encoding/json"
+    "net/http"
+    "slices"
+    "time"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/auth"
+)
+
+const defaultScopedTokenTTL = 24 * time.Hour
+
+// createScopedTokenHandler mints an access token restricted to a subset of
+// the caller's scopes, for handing out to integrations. Scoped tokens have no
+// refresh token; the integration asks the user for a new one when it expires.
+func (cfg *apiConfig) createScopedTokenHandler(w http.ResponseWriter, r *http.Request) {
+    type params struct {
+        Scopes           []string `json:"scopes"`
+        ExpiresInSeconds int      `json:"expires_in_seconds"`
+    }
+
+    type response struct {
+        Token     string    `json:"token"`
+        Scopes    []string  `json:"scopes"`
+        ExpiresAt time.Time `json:"expires_at"`
+    }
+
+    claims := claimsFromContext(r.Context())
+
+    // Only a full login session may mint tokens. A scoped token that could
+    // mint its own successors would never have to expire.
+    if claims.SessionID == "" || claims.Scoped || !slices.Equal(claims.Scopes, auth.DefaultScopes(claims.Role)) {
+        http.Error(w, "Scoped tokens can only be created from a login session", http.StatusForbidden)
+        return
+    }
+
+    var p params
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, "Invalid JSON", http.StatusBadRequest)
+        return
+    }
+
+    if len(p.Scopes) == 0 {
+        http.Error(w, "At least one scope is required", http.StatusBadRequest)
+        return
+    }
+    for _, scope := range p.Scopes {
+        if !auth.ValidScope(scope) {
+            http.Error(w, "Unknown scope: "+scope, http.StatusBadRequest)
+            return
+        }
+        if !claims.HasScope(scope) {
+            http.Error(w, "Cannot grant the "+scope+" scope", http.StatusForbidden)
+            return
+        }
+    }
+
+    maxTTL := cfg.authConfig.ScopedTokenMaxTTL
+    ttl := min(defaultScopedTokenTTL, maxTTL)
+    if p.ExpiresInSeconds < 0 {
+        http.Error(w, "Invalid expires_in_seconds", http.StatusBadRequest)
+        return
+    }
+    if p.ExpiresInSeconds > 0 {
+        ttl = min(time.Duration(p.ExpiresInSeconds)*time.Second, maxTTL)
+    }
+
+    userID := userIDFromContext(r.Context())
+    expiresAt := time.Now().UTC().Add(ttl)
+    // The token stays tied to the caller's session, so logging that session
+    // out revokes it too.
+    sessionID, _ := uuid.Parse(claims.SessionID)
+    token, err := auth.MakeScopedJWT(userID, sessionID, claims.Role, p.Scopes, cfg.jwtKeys, ttl)
+    if err != nil {
+        http.Error(w, "Failed to create token", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(response{
+        Token:     token,
+        Scopes:    p.Scopes,
+        ExpiresAt: expiresAt,
+    })
+}
+
+// jwksHandler publishes the public keys access tokens are verified with, so
+// other services can check tokens without sharing a secret.
+func (cfg *apiConfig) jwksHandler(w http.ResponseWriter, _ *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Cache-Control", "public, max-age=300")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(cfg.jwtKeys.JWKS())
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "github.com/danon29/chippy/internal/auth"
+    "github.com/danon29/chippy/internal/config"
+)
+
+func TestCreateScopedToken(t *testing.T) {
+    cfg := &apiConfig{
+        jwtKeys:    auth.NewHMACKeyring("test-secret"),
+        authConfig: config.Default().Auth,
+    }
+    userID, sessionID := uuid.New(), uuid.New()
+
+    mint := func(t *testing.T, token, body string) *httptest.ResponseRecorder {
+        claims, err := auth.ValidateJWTWithClaims(token, cfg.jwtKeys)
+        require.NoError(t, err)
+        req := httptest.NewRequest(http.MethodPost, "/api/tokens", strings.NewReader(body))
+        req = req.WithContext(context.WithValue(req.Context(), claimsContextKey, claims))
+        rec := httptest.NewRecorder()
+        cfg.createScopedTokenHandler(rec, req)
+        return rec
+    }
+
+    t.Run("session token", func(t *testing.T) {
+        token, err := auth.MakeSessionJWT(userID, sessionID, auth.RoleUser, cfg.jwtKeys, time.Hour)
+        require.NoError(t, err)
+        rec := mint(t, token, `{"scopes":["chirps:write"]}`)
+        assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+    })
+
+    t.Run("scope the caller lacks", func(t *testing.T) {
+        token, err := auth.MakeSessionJWT(userID, sessionID, auth.RoleUser, cfg.jwtKeys, time.Hour)
+        require.NoError(t, err)
+        rec := mint(t, token, `{"scopes":["admin"]}`)
+        assert.Equal(t, http.StatusForbidden, rec.Code)
+    })
+
+    t.Run("scoped token", func(t *testing.T) {
+        scopes := []string{auth.ScopeChirpsWrite, auth.ScopeUsersWrite}
+        token, err := auth.MakeScopedJWT(userID, sessionID, auth.RoleUser, scopes, cfg.jwtKeys, time.Minute)
+        require.NoError(t, err)
+        rec := mint(t, token, `{"scopes":["chirps:write"],"expires_in_seconds":86400}`)
+        assert.Equal(t, http.StatusForbidden, rec.Code)
+    })
+
+    t.Run("token without a session", func(t *testing.T) {
+        token, err := auth.MakeJWT(userID, auth.RoleUser, cfg.jwtKeys, time.Hour)
+        require.NoError(t, err)
+        rec := mint(t, token, `{"scopes":["chirps:write"]}`)
+        assert.Equal(t, http.StatusForbidden, rec.Code)
+    })
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/auth"
+    "github.com/danon29/chippy/internal/database"
+    "github.com/danon29/chippy/internal/totp"
+)
+
+// twoFactorChallengeTTL is how long a user has to enter their code after
+// the password step of a login.
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// errTwoFactorUnavailable means TOTP_ENCRYPTION_KEY is unset, so TOTP
+// secrets can be neither stored nor read.
+var errTwoFactorUnavailable = errors.New("two-factor authentication is not configured")
+
+// writeTwoFactorUnavailable responds to a two-factor request the server
+// cannot serve without TOTP_ENCRYPTION_KEY.
+func writeTwoFactorUnavailable(w http.ResponseWriter) {
+    http.Error(w, "Two-factor authentication is not available on this server", http.StatusServiceUnavailable)
+}
+
+// twoFactorEnabled reports whether userID has confirmed a TOTP secret.
+func (cfg *apiConfig) twoFactorEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+    cred, err := cfg.DB.GetTOTPCredential(ctx, userID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    return cred.ConfirmedAt.Valid, nil
+}
+
+// verifySecondFactor checks a TOTP code, or a recovery code when one is
+// given. Either is used up on success.
+func (cfg *apiConfig) verifySecondFactor(ctx context.Context, userID uuid.UUID, code, recoveryCode string, now time.Time) (bool, error) {
+    if recoveryCode != "" {
+        used, err := cfg.DB.ConsumeRecoveryCode(ctx, database.ConsumeRecoveryCodeParams{
+            UserID:   userID,
+            CodeHash: totp.HashRecoveryCode(recoveryCode),
+        })
+        if err != nil {
+            return false, err
+        }
+        if used == 1 {
+            log.Printf("security: user %s logged in with a recovery code", userID)
+        }
+        return used == 1, nil
+    }
+
+    // Recovery codes are hashed, not encrypted, so they keep working
+    // without the key.
+    if cfg.totpSealer == nil {
+        return false, errTwoFactorUnavailable
+    }
+
+    cred, err := cfg.DB.GetTOTPCredential(ctx, userID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    secret, err := cfg.totpSealer.Open(userID, cred.Secret)
+    if err != nil {
+        return false, err
+    }
+
+    step, ok := totp.Verify(secret, code, now)
+    if !ok {
+        return false, nil
+    }
+    // Recording the step makes the code single-use; a second login with it,
+    // even a concurrent one, matches no row.
+    used, err := cfg.DB.UseTOTPStep(ctx, database.UseTOTPStepParams{
+        UserID:       userID,
+        LastUsedStep: step,
+    })
+    return used == 1, err
+}
+
+// completeTwoFactorLogin is the second step of a login: it trades a
+// challenge token and a valid code for access and refresh tokens.
+func (cfg *apiConfig) completeTwoFactorLogin(w http.ResponseWriter, r *http.Request, ip string, p loginParams) {
+    userID, err := auth.ValidateChallengeToken(p.ChallengeToken, cfg.jwtKeys)
+    if err != nil {
+        http.Error(w, "Invalid or expired challenge token", http.StatusUnauthorized)
+        return
+    }
+
+    user, err := cfg.DB.GetUserByID(r.Context(), userID)
+    if errors.Is(err, sql.ErrNoRows) {
+        http.Error(w, "Invalid or expired challenge token", http.StatusUnauthorized)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Unknown error", http.StatusInternalServerError)
+        return
+    }
+
+    now := time.Now()
+    wait := cfg.loginPolicy.RetryAfter(int(user.FailedLoginCount), user.LastFailedLoginAt.Time, now)
+    if wait > 0 {
+        writeTooManyAttempts(w, wait)
+        return
+    }
+    if user.BannedAt.Valid {
+        http.Error(w, "Account suspended", http.StatusForbidden)
+        return
+    }
+
+    ok, err := cfg.verifySecondFactor(r.Context(), user.ID, p.Code, p.RecoveryCode, now)
+    if errors.Is(err, errTwoFactorUnavailable) {
+        writeTwoFactorUnavailable(w)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Unknown error", http.StatusInternalServerError)
+        return
+    }
+    if !ok {
+        cfg.recordFailedLogin(r.Context(), user.ID, ip, now)
+        http.Error(w, "Incorrect two-factor code", http.StatusUnauthorized)
+        return
+    }
+
+    if user.FailedLoginCount > 0 {
+        cfg.resetFailedLogins(r.Context(), user.ID)
+    }
+
+    cfg.issueLoginTokens(w, r, user)
+}
+
+func (cfg *apiConfig) setupTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+    if cfg.totpSealer == nil {
+        writeTwoFactorUnavailable(w)
+        return
+    }
+    userID := userIDFromContext(r.Context())
+
+    user, err := cfg.DB.GetUserByID(r.Context(), userID)
+    if err != nil {
+        http.Error(w, "Failed to set up two-factor authentication", http.StatusInternalServerError)
+        return
+    }
+
+    secret, err := totp.GenerateSecret()
+    if err != nil {
+        http.Error(w, "Failed to set up two-factor authentication", http.StatusInternalServerError)
+        return
+    }
+
+    sealed, err := cfg.totpSealer.Seal(userID, secret)
+    if err != nil {
+        http.Error(w, "Failed to set up two-factor authentication", http.StatusInternalServerError)
+        return
+    }
+
+    // Setting up again before confirming replaces the pending secret.
+    rows, err := cfg.DB.SetPendingTOTPSecret(r.Context(), database.SetPendingTOTPSecretParams{
+        UserID: userID,
+        Secret: sealed,
+    })
+    if err != nil {
+        http.Error(w, "Failed to set up two-factor authentication", http.StatusInternalServerError)
+        return
+    }
+    if rows == 0 {
+        http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(struct {
+        Secret     string `json:"secret"`
+        OtpauthURI string `json:"otpauth_uri"`
+    }{secret, totp.URI(cfg.authConfig.Issuer, user.Email, secret)})
+}
+
+func (cfg *apiConfig) confirmTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+    if cfg.totpSealer == nil {
+        writeTwoFactorUnavailable(w)
+        return
+    }
+    userID := userIDFromContext(r.Context())
+
+    type params struct {
+        Code string `json:"code"`
+    }
+
+    var p params
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, "Invalid request", http.StatusBadRequest)
+        return
+    }
+
+    cred, err := cfg.DB.GetTOTPCredential(r.Context(), userID)
+    if errors.Is(err, sql.ErrNoRows) {
+        http.Error(w, "Set up two-factor authentication first", http.StatusBadRequest)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to confirm two-factor authentication", http.StatusInternalServerError)
+        return
+    }
+    if cred.ConfirmedAt.Valid {
+        http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+        return
+    }
+
+    secret, err := cfg.totpSealer.Open(userID, cred.Secret)
+    if err != nil {
+        http.Error(w, "Failed to confirm two-factor authentication", http.StatusInternalServerError)
+        return
+    }
+
+    step, ok := totp.Verify(secret, p.Code, time.Now())
+    if !ok {
+        http.Error(w, "Incorrect two-factor code", http.StatusBadRequest)
+        return
+    }
+
+    codes, err := totp.GenerateRecoveryCodes(totp.RecoveryCodeCount)
+    if err != nil {
+        http.Error(w, "Failed to confirm two-factor authentication", http.StatusInternalServerError)
+        return
+    }
+
+    tx, err := cfg.db.BeginTx(r.Context(), nil)
+    if err != nil {
+        http.Error(w, "Failed to confirm two-factor authentication", http.StatusInternalServerError)
+        return
+    }
+    defer tx.Rollback()
+    qtx := cfg.DB.WithTx(tx)
+
+    confirmed, err := qtx.ConfirmTOTPCredential(r.Context(), database.ConfirmTOTPCredentialParams{
+        UserID:       userID,
+        LastUsedStep: step,
+    })
+    if err != nil {
+        http.Error(w, "Failed to confirm two-factor authentication", http.StatusInternalServerError)
+        return
+    }
+    if confirmed == 0 {
+        http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+        return
+    }
+
+    if err := qtx.DeleteRecoveryCodes(r.Context(), userID); err != nil {
+        http.Error(w, "Failed to confirm two-factor authentication", http.StatusInternalServerError)
+        return
+    }
+    for _, code := range codes {
+        err := qtx.CreateRecoveryCode(r.Context(), database.CreateRecoveryCodeParams{
+            UserID:   userID,
+            CodeHash: totp.HashRecoveryCode(code),
+        })
+        if err != nil {
+            http.Error(w, "Failed to confirm two-factor authentication", http.StatusInternalServerError)
+            return
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        http.Error(w, "Failed to confirm two-factor authentication", http.StatusInternalServerError)
+        return
+    }
+
+    // The codes are only ever shown here; we keep just their hashes.
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(struct {
+        RecoveryCodes []string `json:"recovery_codes"`
+    }{codes})
+}
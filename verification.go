@@ -0,0 +1,159 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "net/url"
+    "time"
+
+    "github.com/google/uuid"
+
+    "github.com/danon29/chippy/internal/auth"
+    "github.com/danon29/chippy/internal/config"
+    "github.com/danon29/chippy/internal/database"
+    "github.com/danon29/chippy/internal/mailer"
+)
+
+// newMailer builds the mailer selected by conf.Transport.
+func newMailer(conf config.Mail) mailer.Mailer {
+    switch conf.Transport {
+    case "smtp":
+        return &mailer.SMTPMailer{
+            Addr:     conf.SMTPAddr,
+            From:     conf.From,
+            Username: conf.SMTPUsername,
+            Password: conf.SMTPPassword,
+        }
+    case "file":
+        return &mailer.FileMailer{Dir: conf.Dir, From: conf.From}
+    default:
+        return &mailer.LogMailer{From: conf.From}
+    }
+}
+
+// linkWithToken adds token to the query of page, the URL emailed links
+// point at.
+func linkWithToken(page, token string) (string, error) {
+    link, err := url.Parse(page)
+    if err != nil {
+        return "", err
+    }
+    query := link.Query()
+    query.Set("token", token)
+    link.RawQuery = query.Encode()
+    return link.String(), nil
+}
+
+// sendVerificationEmail mails userID a single-use link proving control of
+// email.
+func (cfg *apiConfig) sendVerificationEmail(ctx context.Context, userID uuid.UUID, email string) error {
+    token, claims, err := auth.MakeEmailVerificationToken(userID, email, cfg.jwtKeys, cfg.authConfig.EmailVerificationTTL)
+    if err != nil {
+        return err
+    }
+
+    // Links that expired unused would otherwise pile up.
+    if err := cfg.DB.DeleteExpiredEmailVerificationTokens(ctx, userID); err != nil {
+        return err
+    }
+
+    err = cfg.DB.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+        Jti:       claims.ID,
+        UserID:    userID,
+        ExpiresAt: claims.ExpiresAt.Time,
+    })
+    if err != nil {
+        return err
+    }
+
+    link, err := linkWithToken(cfg.mailConfig.VerifyURL, token)
+    if err != nil {
+        return err
+    }
+
+    return cfg.mailer.Send(ctx, mailer.Message{
+        To:      email,
+        Subject: "Verify your Chirpy email address",
+        Body: fmt.Sprintf("Confirm that this is your email address by opening the link below.\n\n%s\n\n"+
+            "The link expires in %s. If you did not sign up for Chirpy, ignore this email.\n",
+            link, cfg.authConfig.EmailVerificationTTL),
+    })
+}
+
+// sendVerificationEmailAsync sends the verification email without holding
+// up the response. Failures are only logged, so an unreachable mail server
+// does not stop anyone from signing up.
+func (cfg *apiConfig) sendVerificationEmailAsync(userID uuid.UUID, email string) {
+    go func() {
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+        if err := cfg.sendVerificationEmail(ctx, userID, email); err != nil {
+            log.Printf("Error sending verification email to user %s: %v", userID, err)
+        }
+    }()
+}
+
+func (cfg *apiConfig) verifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+    type params struct {
+        Token string `json:"token"`
+    }
+
+    var p params
+    if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+        http.Error(w, "Invalid request", http.StatusBadRequest)
+        return
+    }
+
+    claims, err := auth.ValidateEmailVerificationToken(p.Token, cfg.jwtKeys)
+    if err != nil {
+        http.Error(w, "Invalid or expired verification token", http.StatusBadRequest)
+        return
+    }
+    userID := uuid.MustParse(claims.Subject)
+
+    tx, err := cfg.db.BeginTx(r.Context(), nil)
+    if err != nil {
+        http.Error(w, "Failed to verify email", http.StatusInternalServerError)
+        return
+    }
+    defer tx.Rollback()
+    qtx := cfg.DB.WithTx(tx)
+
+    consumed, err := qtx.ConsumeEmailVerificationToken(r.Context(), database.ConsumeEmailVerificationTokenParams{
+        Jti:    claims.ID,
+        UserID: userID,
+    })
+    if err != nil {
+        http.Error(w, "Failed to verify email", http.StatusInternalServerError)
+        return
+    }
+    if consumed == 0 {
+        http.Error(w, "Verification token has already been used", http.StatusBadRequest)
+        return
+    }
+
+    // The address may have changed since the link was sent; then the link
+    // proves nothing about the current one.
+    verified, err := qtx.MarkEmailVerified(r.Context(), database.MarkEmailVerifiedParams{
+        ID:    userID,
+        Email: claims.Email,
+    })
+    if err != nil {
+        http.Error(w, "Failed to verify email", http.StatusInternalServerError)
+        return
+    }
+    if verified == 0 {
+        http.Error(w, "Email address has changed since this link was sent", http.StatusBadRequest)
+        return
+    }
+
+    if err := tx.Commit(); err != nil {
+        http.Error(w, "Failed to verify email", http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}